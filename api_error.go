@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/googleapi"
+)
+
+// detailedAPIError is an error holding a googleapi.Error, whose message ends with the structured fields of the latter.
+type detailedAPIError struct {
+	err     error
+	details string
+}
+
+func (e detailedAPIError) Error() string {
+	return fmt.Sprintf("%s (%s)", strings.TrimSpace(e.err.Error()), e.details)
+}
+
+func (e detailedAPIError) Unwrap() error {
+	return e.err
+}
+
+// describeAPIError spells out the status, the reasons and the nested details of the googleapi.Error in err, if any,
+// e.g. "googleapi: Error 403: Forbidden, forbidden (status=403 reason=insufficientPermissions)",
+// which the message of a googleapi.Error does not always tell. The other errors are returned as is.
+func describeAPIError(err error) error {
+	var apiErr *googleapi.Error
+	var alreadyDetailed detailedAPIError
+	if !errors.As(err, &apiErr) || errors.As(err, &alreadyDetailed) {
+		return err
+	}
+
+	fields := []string{fmt.Sprintf("status=%d", apiErr.Code)}
+	var reasons []string
+	for _, item := range apiErr.Errors {
+		if item.Reason != "" {
+			reasons = append(reasons, item.Reason)
+		}
+	}
+	if len(reasons) > 0 {
+		fields = append(fields, "reason="+strings.Join(reasons, ","))
+	}
+	var details []string
+	for _, detail := range apiErr.Details {
+		if detail, ok := detail.(map[string]interface{}); ok {
+			// e.g. type.googleapis.com/google.rpc.ErrorInfo
+			description := path.Base(fmt.Sprint(detail["@type"]))
+			if reason, ok := detail["reason"].(string); ok {
+				description = fmt.Sprintf("%s(%s)", description, reason)
+			}
+			details = append(details, description)
+		}
+	}
+	if len(details) > 0 {
+		fields = append(fields, "details="+strings.Join(details, ","))
+	}
+
+	return detailedAPIError{err: err, details: strings.Join(fields, " ")}
+}
+
+// withAPIErrorDetails wraps a CRUD function so that the API errors it returns spell out their structured fields.
+func withAPIErrorDetails(f func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+	return func(resourceData *schema.ResourceData, provider interface{}) error {
+		return describeAPIError(f(resourceData, provider))
+	}
+}
+
+// describeAPIErrors wraps the CRUD and import functions of every resource and data source of the provider,
+// so that the API errors they return spell out their structured fields.
+func describeAPIErrors(provider *schema.Provider) {
+	for _, resources := range []map[string]*schema.Resource{provider.ResourcesMap, provider.DataSourcesMap} {
+		for _, resource := range resources {
+			if resource.Create != nil {
+				resource.Create = withAPIErrorDetails(resource.Create)
+			}
+			if resource.Read != nil {
+				resource.Read = withAPIErrorDetails(resource.Read)
+			}
+			if resource.Update != nil {
+				resource.Update = withAPIErrorDetails(resource.Update)
+			}
+			if resource.Delete != nil {
+				resource.Delete = withAPIErrorDetails(resource.Delete)
+			}
+			if resource.Importer != nil && resource.Importer.State != nil {
+				state := resource.Importer.State
+				resource.Importer.State = func(resourceData *schema.ResourceData, provider interface{}) ([]*schema.ResourceData, error) {
+					imported, importErr := state(resourceData, provider)
+					return imported, describeAPIError(importErr)
+				}
+			}
+		}
+	}
+}
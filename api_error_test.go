@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/googleapi"
+)
+
+func TestDescribeAPIError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "reason",
+			err:      &googleapi.Error{Code: http.StatusForbidden, Message: "Forbidden", Errors: []googleapi.ErrorItem{{Reason: "insufficientPermissions", Message: "Forbidden"}}},
+			expected: "googleapi: Error 403: Forbidden, insufficientPermissions (status=403 reason=insufficientPermissions)",
+		},
+		{
+			name: "details",
+			err: fmt.Errorf("updating the owners of dns://example.com: %w", &googleapi.Error{Code: http.StatusForbidden, Message: "Disabled", Details: []interface{}{
+				map[string]interface{}{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "SERVICE_DISABLED"},
+			}}),
+			expected: "updating the owners of dns://example.com: googleapi: Error 403: Disabled\nDetails:\n[\n  {\n    \"@type\": \"type.googleapis.com/google.rpc.ErrorInfo\",\n    \"reason\": \"SERVICE_DISABLED\"\n  }\n] (status=403 details=google.rpc.ErrorInfo(SERVICE_DISABLED))",
+		},
+		{
+			name:     "other error",
+			err:      errors.New("no API involved"),
+			expected: "no API involved",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			described := describeAPIError(testCase.err)
+			if described.Error() != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, described)
+			}
+			if httpStatusCode(described) != httpStatusCode(testCase.err) {
+				t.Errorf("expected the API error to still be found in %v", described)
+			}
+			if again := describeAPIError(described); again.Error() != described.Error() {
+				t.Errorf("expected the details to be added only once, got %q", again)
+			}
+		})
+	}
+
+	if describeAPIError(nil) != nil {
+		t.Error("expected no error to stay no error")
+	}
+}
+
+func TestDescribeAPIErrorsOfEveryResource(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"error": {"code": 403, "message": "Forbidden", "errors": [{"reason": "insufficientPermissions", "message": "Forbidden"}]}}`)
+	})
+	schemaProvider := Provider().(*schema.Provider)
+
+	testCases := []struct {
+		name     string
+		resource *schema.Resource
+		config   map[string]interface{}
+		id       string
+	}{
+		{name: "googlesiteverification_site", resource: schemaProvider.ResourcesMap["googlesiteverification_site"], config: map[string]interface{}{siteKey: "https://example.com/"}, id: "https://example.com/"},
+		{name: "googlesiteverification_dns_managed", resource: schemaProvider.ResourcesMap["googlesiteverification_dns_managed"], config: map[string]interface{}{domainKey: "example.com", projectKey: "project", managedZoneKey: "zone"}, id: "dns://example.com"},
+		{name: "data.googlesiteverification_existing_token", resource: schemaProvider.DataSourcesMap["googlesiteverification_existing_token"], config: map[string]interface{}{domainKey: "example.com"}},
+		{name: "data.googlesiteverification_status", resource: schemaProvider.DataSourcesMap["googlesiteverification_status"], config: map[string]interface{}{domainKey: "example.com"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resourceData := schema.TestResourceDataRaw(t, testCase.resource.Schema, testCase.config)
+			resourceData.SetId(testCase.id)
+			readErr := testCase.resource.Read(resourceData, provider)
+			if readErr == nil || !strings.Contains(readErr.Error(), "reason=insufficientPermissions") {
+				t.Errorf("expected the reason of the API error to be spelled out, got %v", readErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/logging/v2"
+	"google.golang.org/api/option"
+)
+
+const auditLogNameKey = "audit_log_name"
+
+// auditEntry is the JSON payload of the audit log entries.
+type auditEntry struct {
+	Action    string `json:"action"`
+	Domain    string `json:"domain"`
+	Method    string `json:"method,omitempty"`
+	Principal string `json:"principal,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+// auditLogger writes an entry to Cloud Logging for each verification and unverification.
+// A nil auditLogger writes nothing.
+type auditLogger struct {
+	service   *logging.Service
+	logName   string
+	projectID string
+	principal string
+}
+
+// newAuditLogger returns an auditLogger writing to the given log, or nil if logName is empty.
+// logName is either a full log resource name such as "projects/my-project/logs/site-verification",
+// or a log ID written in the project of the credentials.
+func newAuditLogger(ctx context.Context, logName string, credentials *google.Credentials, principal string, clientOptions []option.ClientOption) (*auditLogger, error) {
+	if logName == "" {
+		return nil, nil
+	}
+
+	var projectID string
+	if strings.HasPrefix(logName, "projects/") {
+		parts := strings.SplitN(logName, "/", 4)
+		if len(parts) != 4 || parts[1] == "" || parts[2] != "logs" || parts[3] == "" {
+			return nil, fmt.Errorf("%s must be either a log ID or of the form projects/PROJECT_ID/logs/LOG_ID, got %q", auditLogNameKey, logName)
+		}
+		projectID = parts[1]
+	} else {
+		if credentials.ProjectID == "" {
+			return nil, fmt.Errorf("%s must be of the form projects/PROJECT_ID/logs/LOG_ID, as the credentials don't tell which project to log to", auditLogNameKey)
+		}
+		projectID = credentials.ProjectID
+		logName = fmt.Sprintf("projects/%s/logs/%s", projectID, logName)
+	}
+
+	service, serviceErr := logging.NewService(ctx, clientOptions...)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	return &auditLogger{
+		service:   service,
+		logName:   logName,
+		projectID: projectID,
+		principal: principal,
+	}, nil
+}
+
+// record writes an audit entry for the action on the domain.
+// Failing to write it is logged, but never fails the operation.
+func (logger *auditLogger) record(action string, domain string, method string) {
+	if logger == nil {
+		return
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	payload, marshalErr := json.Marshal(auditEntry{
+		Action:    action,
+		Domain:    domain,
+		Method:    method,
+		Principal: logger.principal,
+		Timestamp: now,
+	})
+	if marshalErr != nil {
+		log.Printf("[WARN] could not write the audit log entry of %s: %s", domain, marshalErr)
+		return
+	}
+
+	_, writeErr := logger.service.Entries.Write(&logging.WriteLogEntriesRequest{
+		LogName: logger.logName,
+		Resource: &logging.MonitoredResource{
+			Type:   "global",
+			Labels: map[string]string{"project_id": logger.projectID},
+		},
+		Entries: []*logging.LogEntry{{
+			JsonPayload: googleapi.RawMessage(payload),
+			Severity:    "NOTICE",
+			Timestamp:   now,
+		}},
+	}).Do()
+	if writeErr != nil {
+		log.Printf("[WARN] could not write the audit log entry of %s: %s", domain, writeErr)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/logging/v2"
+	"google.golang.org/api/option"
+)
+
+func TestNewAuditLogger(t *testing.T) {
+	testCases := []struct {
+		name              string
+		logName           string
+		projectID         string
+		expectedLogName   string
+		expectedProjectID string
+		fails             bool
+	}{
+		{name: "disabled", logName: ""},
+		{name: "full name", logName: "projects/audit/logs/site-verification", projectID: "other", expectedLogName: "projects/audit/logs/site-verification", expectedProjectID: "audit"},
+		{name: "log ID", logName: "site-verification", projectID: "my-project", expectedLogName: "projects/my-project/logs/site-verification", expectedProjectID: "my-project"},
+		{name: "log ID without project", logName: "site-verification", fails: true},
+		{name: "malformed full name", logName: "projects/audit/site-verification", fails: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			logger, loggerErr := newAuditLogger(context.Background(), testCase.logName, &google.Credentials{ProjectID: testCase.projectID}, "", []option.ClientOption{option.WithoutAuthentication()})
+			if testCase.fails {
+				if loggerErr == nil {
+					t.Error("expected the audit log name to be rejected")
+				}
+				return
+			}
+			if loggerErr != nil {
+				t.Fatal(loggerErr)
+			}
+			if testCase.logName == "" {
+				if logger != nil {
+					t.Error("expected no audit logger without a log name")
+				}
+				return
+			}
+			if logger.logName != testCase.expectedLogName || logger.projectID != testCase.expectedProjectID {
+				t.Errorf("expected the log %s of %s, got %s of %s", testCase.expectedLogName, testCase.expectedProjectID, logger.logName, logger.projectID)
+			}
+		})
+	}
+}
+
+func TestAuditLoggerRecord(t *testing.T) {
+	var requests []logging.WriteLogEntriesRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request logging.WriteLogEntriesRequest
+		if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+			t.Fatal(decodeErr)
+		}
+		requests = append(requests, request)
+		if len(requests) > 1 {
+			writeAPIError(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	logger, loggerErr := newAuditLogger(context.Background(), "projects/audit/logs/site-verification", &google.Credentials{}, "owner@example.com", []option.ClientOption{option.WithEndpoint(server.URL + "/"), option.WithoutAuthentication()})
+	if loggerErr != nil {
+		t.Fatal(loggerErr)
+	}
+
+	logger.record("create", "example.com", "DNS_TXT")
+	// a failure to write the entry must not fail, nor panic
+	logger.record("delete", "example.com", "")
+	var nilLogger *auditLogger
+	nilLogger.record("create", "example.com", "DNS_TXT")
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 entries to be written, got %d", len(requests))
+	}
+	request := requests[0]
+	if request.LogName != "projects/audit/logs/site-verification" || request.Resource.Labels["project_id"] != "audit" {
+		t.Errorf("expected the entry to go to the site-verification log of audit, got %s of %v", request.LogName, request.Resource.Labels)
+	}
+	var entry auditEntry
+	if unmarshalErr := json.Unmarshal(request.Entries[0].JsonPayload, &entry); unmarshalErr != nil {
+		t.Fatal(unmarshalErr)
+	}
+	if entry.Action != "create" || entry.Domain != "example.com" || entry.Method != "DNS_TXT" || entry.Principal != "owner@example.com" {
+		t.Errorf("unexpected audit entry %+v", entry)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/siteverification/v1"
+)
+
+// validateCredentials mints an access token, to catch credentials problems before any API call.
+func validateCredentials(credentials *google.Credentials) error {
+	if _, tokenErr := credentials.TokenSource.Token(); tokenErr != nil {
+		if isClockSkewError(tokenErr) {
+			return fmt.Errorf("could not get an access token because the system clock seems to be skewed: "+
+				"make sure it is synchronized (e.g. with NTP) and try again: %w", tokenErr)
+		}
+		return fmt.Errorf("could not get an access token with the provided credentials: %w", tokenErr)
+	}
+	return nil
+}
+
+// validateAPIAccess checks that the credentials can call the Site Verification API, to tell what they miss,
+// such as the OAuth scope, rather than let the first API call fail with a bare 403.
+func validateAPIAccess(ctx context.Context, service *siteverification.Service) error {
+	missing, checkErr := missingPermissions(ctx, service)
+	if checkErr != nil {
+		return fmt.Errorf("could not check the access of the provided credentials to the Site Verification API: %w", checkErr)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the provided credentials are missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// verifyOnlyScopes reports whether the configured scopes don't include the full Site Verification scope,
+// in which case the credentials are expected to be refused the listing the API access check relies on.
+func verifyOnlyScopes(resourceData *schema.ResourceData) bool {
+	configuredScopes := resourceData.Get(scopesKey).([]interface{})
+	if len(configuredScopes) == 0 {
+		return false
+	}
+	for _, scope := range configuredScopes {
+		if scope.(string) == siteverification.SiteverificationScope {
+			return false
+		}
+	}
+	return true
+}
+
+// isClockSkewError reports whether an OAuth2 error comes from a JWT rejected because of its timestamps,
+// which happens when the local clock is too far off from Google's.
+func isClockSkewError(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "invalid jwt") &&
+		(strings.Contains(message, "iat") || strings.Contains(message, "used too early") || strings.Contains(message, "reasonable timeframe"))
+}
+
+// credentialsEmail returns the email of the service account the credentials belong to,
+// or an empty string when the credentials do not tell (e.g. user credentials).
+func credentialsEmail(credentials *google.Credentials) string {
+	var serviceAccount struct {
+		ClientEmail string `json:"client_email"`
+	}
+	if len(credentials.JSON) == 0 || json.Unmarshal(credentials.JSON, &serviceAccount) != nil {
+		return ""
+	}
+	return serviceAccount.ClientEmail
+}
+
+// base64CredentialsJSON decodes credentials given as base64-encoded JSON.
+// It reports false for anything else, e.g. the JSON itself or the path of a key file.
+func base64CredentialsJSON(literal string) ([]byte, bool) {
+	decoded, decodeErr := base64.StdEncoding.DecodeString(strings.TrimSpace(literal))
+	if decodeErr != nil || !json.Valid(decoded) {
+		return nil, false
+	}
+	return decoded, true
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+const domainStatusKey = "domain_status"
+
+// verifiedStatus is the status of the domains of a batch which are verified, the others have the error they failed with.
+const verifiedStatus = "VERIFIED"
+
+func dnsBatchSiteVerificationResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainsKey: {
+				Type:        schema.TypeSet,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains you want to verify. The DNS records of their tokens must already exist.",
+			},
+			methodKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "DNS_TXT",
+				ValidateFunc: validation.StringInSlice(dnsVerificationMethods, false),
+				Description:  "The DNS verification method of all the domains.",
+			},
+			tokensKey: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The tokens of the domains, by domain, e.g. the `tokens` of data.googlesiteverification_dns_tokens. When set, every domain must have its token, and the domains whose token changes are verified again.",
+			},
+			concurrencyKey: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      4,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "How many domains to verify or unverify at the same time, to stay within the API rate limits.",
+			},
+			domainStatusKey: {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains, mapped to `VERIFIED` or to the error their last verification failed with. The failed domains are tried again on the next apply. Check it after the creation, which does not fail when some domains do, as the batch would then be replaced, unverifying the others.",
+			},
+		},
+		Create:        createDnsBatchSiteVerification,
+		Read:          readDnsBatchSiteVerification,
+		Update:        updateDnsBatchSiteVerification,
+		Delete:        deleteDnsBatchSiteVerification,
+		CustomizeDiff: customizeDnsBatchDiff,
+		Description:   "Verifies many domains with the same DNS verification method, making a bounded number of API calls at once rather than one per resource. The domains which fail are reported all at once, and only them are tried again on the next apply, as an update of the batch. The creation only logs them, in `domain_status` and as warnings, not to be replaced and unverify the others.",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+// customizeDnsBatchDiff plans an update whenever a domain is not verified yet, so the failures are tried again,
+// and marks the statuses as unknown when the domains or their tokens change.
+func customizeDnsBatchDiff(diff *schema.ResourceDiff, provider interface{}) error {
+	if diff.Id() == "" {
+		return nil
+	}
+	if diff.HasChange(domainsKey) || diff.HasChange(tokensKey) || !diff.NewValueKnown(domainsKey) {
+		return diff.SetNewComputed(domainStatusKey)
+	}
+
+	domainStatus := diff.Get(domainStatusKey).(map[string]interface{})
+	for _, domain := range diff.Get(domainsKey).(*schema.Set).List() {
+		if domainStatus[domain.(string)] != verifiedStatus {
+			return diff.SetNewComputed(domainStatusKey)
+		}
+	}
+	return nil
+}
+
+func createDnsBatchSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	resourceData.SetId(resource.UniqueId())
+
+	failures, syncErr := syncDnsBatch(resourceData, provider.(configuredProvider), resourceData.Timeout(schema.TimeoutCreate), map[string]interface{}{})
+	if syncErr != nil {
+		return syncErr
+	}
+	if len(failures) > 0 {
+		// failing would taint the batch, whose replacement would unverify the domains which succeeded:
+		// the failed domains are in domain_status instead, and the next plan updates the batch to try them again
+		log.Printf("[WARN] some domains of the batch %s could not be verified, they will be tried again on the next apply: %s", resourceData.Id(), batchErr(failures))
+	}
+	return nil
+}
+
+func updateDnsBatchSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	previousTokens, _ := resourceData.GetChange(tokensKey)
+
+	failures, syncErr := syncDnsBatch(resourceData, provider.(configuredProvider), resourceData.Timeout(schema.TimeoutUpdate), previousTokens.(map[string]interface{}))
+	if syncErr != nil {
+		return syncErr
+	}
+	return batchErr(failures)
+}
+
+func readDnsBatchSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	domainStatus := resourceData.Get(domainStatusKey).(map[string]interface{})
+
+	var verified []string
+	for domain, status := range domainStatus {
+		if status == verifiedStatus {
+			verified = append(verified, domain)
+		}
+	}
+	sort.Strings(verified)
+
+	var statusMu sync.Mutex
+	failures := forEachConcurrently(verified, resourceData.Get(concurrencyKey).(int), nil, func(domain string) error {
+		_, getErr := getWebResource(provider.(configuredProvider), webResourceID(domain))
+		if httpStatusCode(getErr) == http.StatusNotFound {
+			// unverified out of band: it will be verified again on the next apply
+			statusMu.Lock()
+			defer statusMu.Unlock()
+			delete(domainStatus, domain)
+			return nil
+		}
+		return getErr
+	})
+	if len(failures) > 0 {
+		return batchErr(failures)
+	}
+
+	return resourceData.Set(domainStatusKey, domainStatus)
+}
+
+func deleteDnsBatchSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	domainStatus := resourceData.Get(domainStatusKey).(map[string]interface{})
+	deadline := time.Now().Add(resourceData.Timeout(schema.TimeoutDelete))
+
+	var verified []string
+	for domain, status := range domainStatus {
+		if status == verifiedStatus {
+			verified = append(verified, domain)
+		}
+	}
+	sort.Strings(verified)
+
+	failures := forEachConcurrently(verified, resourceData.Get(concurrencyKey).(int), nil, func(domain string) error {
+		return deleteSiteVerification(provider.(configuredProvider), time.Until(deadline), webResourceID(domain))
+	})
+	if len(failures) > 0 {
+		// the domains still verified must stay in the state, so their deletion can be tried again
+		remaining := make(map[string]interface{}, len(failures))
+		for domain := range failures {
+			remaining[domain] = verifiedStatus
+		}
+		if setErr := resourceData.Set(domainStatusKey, remaining); setErr != nil {
+			return setErr
+		}
+		return batchErr(failures)
+	}
+
+	return nil
+}
+
+// syncDnsBatch unverifies the domains removed from the batch, then verifies the domains which are not verified yet,
+// or whose token changed since previousTokens. The status of every domain is recorded in the state, even on failure.
+// It returns the failures by domain, the error being for the failures to record the statuses.
+func syncDnsBatch(resourceData *schema.ResourceData, provider configuredProvider, timeout time.Duration, previousTokens map[string]interface{}) (map[string]error, error) {
+	deadline := time.Now().Add(timeout)
+	method := resourceData.Get(methodKey).(string)
+	concurrency := resourceData.Get(concurrencyKey).(int)
+	tokens := resourceData.Get(tokensKey).(map[string]interface{})
+
+	wanted := map[string]bool{}
+	for _, domain := range resourceData.Get(domainsKey).(*schema.Set).List() {
+		wanted[domain.(string)] = true
+	}
+
+	var statusMu sync.Mutex
+	domainStatus := resourceData.Get(domainStatusKey).(map[string]interface{})
+	setStatus := func(domain string, status interface{}) {
+		statusMu.Lock()
+		defer statusMu.Unlock()
+		if status == nil {
+			delete(domainStatus, domain)
+		} else {
+			domainStatus[domain] = status
+		}
+	}
+
+	var removed, toVerify []string
+	for domain, status := range domainStatus {
+		if !wanted[domain] {
+			if status == verifiedStatus {
+				removed = append(removed, domain)
+			} else {
+				delete(domainStatus, domain)
+			}
+		}
+	}
+	for domain := range wanted {
+		tokenChanged := previousTokens[domain] != nil && previousTokens[domain] != tokens[domain]
+		if domainStatus[domain] != verifiedStatus || tokenChanged {
+			toVerify = append(toVerify, domain)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(toVerify)
+
+	failures := forEachConcurrently(removed, concurrency, nil, func(domain string) error {
+		if deleteErr := deleteSiteVerification(provider, time.Until(deadline), webResourceID(domain)); deleteErr != nil {
+			return deleteErr
+		}
+		setStatus(domain, nil)
+		return nil
+	})
+
+	insertFailures := forEachConcurrently(toVerify, concurrency, nil, func(domain string) error {
+		var verifyErr error
+		if _, hasToken := tokens[domain]; len(tokens) > 0 && !hasToken {
+			verifyErr = errors.New("no token is given for it in tokens")
+		} else {
+			_, verifyErr = insertSiteVerification(provider, time.Until(deadline), domain, method)
+		}
+		if verifyErr != nil {
+			setStatus(domain, verifyErr.Error())
+			return verifyErr
+		}
+		setStatus(domain, verifiedStatus)
+		return nil
+	})
+	for domain, failure := range insertFailures {
+		failures[domain] = failure
+	}
+
+	// whatever got verified must be kept in the state, so it can be deleted later
+	if setErr := resourceData.Set(domainStatusKey, domainStatus); setErr != nil {
+		return nil, setErr
+	}
+	return failures, nil
+}
+
+// batchErr describes the failures of the domains of a batch, sorted by domain.
+func batchErr(failures map[string]error) error {
+	domains := make([]string, 0, len(failures))
+	for domain := range failures {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	var err *multierror.Error
+	for _, domain := range domains {
+		err = multierror.Append(err, fmt.Errorf("%s: %w", domain, failures[domain]))
+	}
+	return err.ErrorOrNil()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/siteverification/v1"
+)
+
+func TestSyncDnsBatchRetriesOnlyFailures(t *testing.T) {
+	var insertedMu sync.Mutex
+	var inserted []string
+	failing := "b.example.com"
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var webResource siteverification.SiteVerificationWebResourceResource
+		if decodeErr := json.NewDecoder(r.Body).Decode(&webResource); decodeErr != nil {
+			t.Fatal(decodeErr)
+		}
+		domain := webResource.Site.Identifier
+
+		insertedMu.Lock()
+		inserted = append(inserted, domain)
+		insertedMu.Unlock()
+
+		if domain == failing {
+			writeAPIError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id": "dns%%3A%%2F%%2F%s", "site": {"identifier": %q, "type": "INET_DOMAIN"}}`, domain, domain)
+	})
+
+	resourceData := schema.TestResourceDataRaw(t, dnsBatchSiteVerificationResource().Schema, map[string]interface{}{
+		domainsKey: []interface{}{"a.example.com", "b.example.com", "c.example.com"},
+	})
+	if createErr := createDnsBatchSiteVerification(resourceData, provider); createErr != nil {
+		t.Fatalf("expected the creation not to fail, which would taint the batch, got %v", createErr)
+	}
+	domainStatus := resourceData.Get(domainStatusKey).(map[string]interface{})
+	if domainStatus["a.example.com"] != verifiedStatus || domainStatus["c.example.com"] != verifiedStatus {
+		t.Errorf("expected the other domains to be verified, got %v", domainStatus)
+	}
+	if domainStatus["b.example.com"] == verifiedStatus {
+		t.Errorf("expected b.example.com to have its error as status, got %v", domainStatus)
+	}
+
+	failing = ""
+	inserted = nil
+	if updateErr := updateDnsBatchSiteVerification(resourceData, provider); updateErr != nil {
+		t.Fatal(updateErr)
+	}
+	if strings.Join(inserted, ",") != "b.example.com" {
+		t.Errorf("expected only b.example.com to be verified again, got %v", inserted)
+	}
+	if status := resourceData.Get(domainStatusKey).(map[string]interface{})["b.example.com"]; status != verifiedStatus {
+		t.Errorf("expected b.example.com to be verified, got %v", status)
+	}
+}
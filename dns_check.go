@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+const dnsCheckKey = "dns_check"
+const dnsCheckServersKey = "dns_check_servers"
+const deleteWaitForRecordRemovalKey = "delete_wait_for_record_removal"
+
+// dnsPort is the port of the DNS servers which don't mention one.
+const dnsPort = "53"
+
+// dnsResolver is the part of net.Resolver the DNS checks need.
+type dnsResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// newResolver returns a resolver querying the servers in turn, or the system's resolver when there are none.
+func newResolver(servers []string) *net.Resolver {
+	if len(servers) == 0 {
+		return net.DefaultResolver
+	}
+	addresses := make([]string, len(servers))
+	for i, server := range servers {
+		if _, _, splitErr := net.SplitHostPort(server); splitErr != nil {
+			server = net.JoinHostPort(server, dnsPort)
+		}
+		addresses[i] = server
+	}
+
+	var queries uint32
+	var dialer net.Dialer
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+			// the resolver dials again when a server fails, which then goes to the next one
+			address := addresses[atomic.AddUint32(&queries, 1)%uint32(len(addresses))]
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// recordInDNS reports whether the record can be resolved, querying the record type the verification method relies on.
+func recordInDNS(ctx context.Context, resolver dnsResolver, record dnsRecord) (bool, error) {
+	switch record.recordType {
+	case "TXT":
+		values, lookupErr := resolver.LookupTXT(ctx, record.name)
+		if lookupErr != nil {
+			return false, lookupErr
+		}
+		for _, value := range values {
+			if value == record.value {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "CNAME":
+		target, lookupErr := resolver.LookupCNAME(ctx, record.name)
+		if lookupErr != nil {
+			return false, lookupErr
+		}
+		return strings.EqualFold(strings.TrimSuffix(target, "."), strings.TrimSuffix(record.value, ".")), nil
+	default:
+		return false, fmt.Errorf("can't check %s records", record.recordType)
+	}
+}
+
+// warnIfRecordNotInDNS logs a warning when the verification record can't be resolved.
+// It never fails: the verification still stands until Google checks the records again.
+func warnIfRecordNotInDNS(ctx context.Context, resolver dnsResolver, record dnsRecord) {
+	found, lookupErr := recordInDNS(ctx, resolver, record)
+	if lookupErr != nil {
+		log.Printf("[WARN] could not check the %s records of %s: %s", record.recordType, record.name, lookupErr)
+		return
+	}
+	if !found {
+		log.Printf("[WARN] no %s record of %s holds %q: the verification will be lost the next time Google checks it", record.recordType, record.name, record.value)
+	}
+}
+
+// waitForRecordRemoval waits until the record can't be resolved anymore, so that Google won't find it either.
+// A name which doesn't exist at all counts as removed.
+func waitForRecordRemoval(ctx context.Context, resolver dnsResolver, b backoff, timeout time.Duration, record dnsRecord) error {
+	start := time.Now()
+	retryErr := b.retry(timeout, func() *resource.RetryError {
+		found, lookupErr := recordInDNS(ctx, resolver, record)
+		var dnsErr *net.DNSError
+		if errors.As(lookupErr, &dnsErr) && dnsErr.IsNotFound {
+			return nil
+		}
+		if lookupErr != nil {
+			log.Printf("[DEBUG] could not check the %s records of %s, trying again: %s", record.recordType, record.name, lookupErr)
+			return resource.RetryableError(lookupErr)
+		}
+		if found {
+			log.Printf("[INFO] waiting for the DNS %s record of %s to be removed from the resolvers", record.recordType, record.name)
+			return resource.RetryableError(fmt.Errorf("the DNS %s record of %s still holds %q", record.recordType, record.name, record.value))
+		}
+		return nil
+	})
+	if retryErr != nil {
+		return fmt.Errorf("waited %s for the DNS record to be removed: %w", time.Since(start).Round(time.Second), retryErr)
+	}
+	return nil
+}
+
+// waitForRecord waits until the record can be resolved, so that Google will find it too.
+func waitForRecord(ctx context.Context, resolver dnsResolver, b backoff, timeout time.Duration, record dnsRecord) error {
+	start := time.Now()
+	retryErr := b.retry(timeout, func() *resource.RetryError {
+		found, lookupErr := recordInDNS(ctx, resolver, record)
+		if lookupErr != nil {
+			log.Printf("[DEBUG] could not resolve the %s records of %s, trying again: %s", record.recordType, record.name, lookupErr)
+			return resource.RetryableError(lookupErr)
+		}
+		if !found {
+			log.Printf("[INFO] waiting for the DNS %s record of %s to propagate", record.recordType, record.name)
+			return resource.RetryableError(fmt.Errorf("no DNS %s record of %s holds %q", record.recordType, record.name, record.value))
+		}
+		return nil
+	})
+	if retryErr != nil {
+		return fmt.Errorf("waited %s for the DNS record to propagate: %w", time.Since(start).Round(time.Second), retryErr)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeResolver answers DNS queries from its maps, and fails for any other name.
+type fakeResolver struct {
+	txt   map[string][]string
+	cname map[string]string
+}
+
+var errNoSuchHost = errors.New("no such host")
+
+func (resolver fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if values, ok := resolver.txt[name]; ok {
+		return values, nil
+	}
+	return nil, errNoSuchHost
+}
+
+func (resolver fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if target, ok := resolver.cname[host]; ok {
+		return target, nil
+	}
+	return "", errNoSuchHost
+}
+
+func TestRecordInDNS(t *testing.T) {
+	resolver := fakeResolver{
+		txt: map[string][]string{
+			"example.com": {"v=spf1 -all", "google-site-verification=abc"},
+		},
+		cname: map[string]string{
+			"abc123.example.com": "gv-xyz.dv.googlehosted.com.",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		record   dnsRecord
+		expected bool
+		fails    bool
+	}{
+		{name: "TXT found", record: dnsRecord{recordType: "TXT", name: "example.com", value: "google-site-verification=abc"}, expected: true},
+		{name: "TXT with another value", record: dnsRecord{recordType: "TXT", name: "example.com", value: "google-site-verification=def"}, expected: false},
+		{name: "TXT of unknown name", record: dnsRecord{recordType: "TXT", name: "unknown.example.com", value: "google-site-verification=abc"}, fails: true},
+		{name: "CNAME found", record: dnsRecord{recordType: "CNAME", name: "abc123.example.com", value: "gv-xyz.dv.googlehosted.com"}, expected: true},
+		{name: "CNAME with another target", record: dnsRecord{recordType: "CNAME", name: "abc123.example.com", value: "gv-other.dv.googlehosted.com"}, expected: false},
+		{name: "CNAME is not looked up as TXT", record: dnsRecord{recordType: "CNAME", name: "example.com", value: "google-site-verification=abc"}, fails: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			found, lookupErr := recordInDNS(context.Background(), resolver, testCase.record)
+			if testCase.fails {
+				if lookupErr == nil {
+					t.Error("expected the lookup to fail")
+				}
+				return
+			}
+			if lookupErr != nil {
+				t.Fatal(lookupErr)
+			}
+			if found != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, found)
+			}
+		})
+	}
+}
+
+func TestWaitForRecordRemoval(t *testing.T) {
+	record := dnsRecord{recordType: "TXT", name: "example.com", value: "google-site-verification=abc"}
+	b := backoff{baseDelay: time.Millisecond, multiplier: 1}
+
+	if waitErr := waitForRecordRemoval(context.Background(), fakeResolver{txt: map[string][]string{"example.com": {"v=spf1 -all"}}}, b, time.Second, record); waitErr != nil {
+		t.Errorf("expected a removed record not to be waited for, got %s", waitErr)
+	}
+	if waitErr := waitForRecordRemoval(context.Background(), notFoundResolver{}, b, time.Second, record); waitErr != nil {
+		t.Errorf("expected a name which doesn't exist not to be waited for, got %s", waitErr)
+	}
+	if waitErr := waitForRecordRemoval(context.Background(), fakeResolver{txt: map[string][]string{"example.com": {record.value}}}, b, 20*time.Millisecond, record); waitErr == nil {
+		t.Error("expected the wait for a record still resolved to time out")
+	}
+}
+
+// notFoundResolver answers that no name exists.
+type notFoundResolver struct{}
+
+func (notFoundResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (notFoundResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestWaitForRecord(t *testing.T) {
+	record := dnsRecord{recordType: "TXT", name: "example.com", value: "google-site-verification=abc"}
+	b := backoff{baseDelay: time.Millisecond, multiplier: 1}
+
+	if waitErr := waitForRecord(context.Background(), fakeResolver{txt: map[string][]string{"example.com": {"v=spf1 -all", record.value}}}, b, time.Second, record); waitErr != nil {
+		t.Errorf("expected a resolved record not to be waited for, got %s", waitErr)
+	}
+	if waitErr := waitForRecord(context.Background(), notFoundResolver{}, b, 20*time.Millisecond, record); waitErr == nil {
+		t.Error("expected the wait for a name which doesn't exist to time out")
+	}
+	if waitErr := waitForRecord(context.Background(), fakeResolver{txt: map[string][]string{"example.com": {"v=spf1 -all"}}}, b, 20*time.Millisecond, record); waitErr == nil {
+		t.Error("expected the wait for a record with another value to time out")
+	}
+}
+
+func TestWarnIfRecordNotInDNS(t *testing.T) {
+	record := dnsRecord{recordType: "TXT", name: "example.com", value: "google-site-verification=abc"}
+
+	testCases := []struct {
+		name     string
+		resolver dnsResolver
+		warning  string
+	}{
+		{name: "record found", resolver: fakeResolver{txt: map[string][]string{"example.com": {record.value}}}},
+		{name: "record with another value", resolver: fakeResolver{txt: map[string][]string{"example.com": {"v=spf1 -all"}}}, warning: "no TXT record of example.com holds"},
+		{name: "lookup failure", resolver: notFoundResolver{}, warning: "could not check the TXT records of example.com"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var output bytes.Buffer
+			log.SetOutput(&output)
+			defer log.SetOutput(os.Stderr)
+
+			warnIfRecordNotInDNS(context.Background(), testCase.resolver, record)
+
+			logged := output.String()
+			if testCase.warning == "" {
+				if strings.Contains(logged, "[WARN]") {
+					t.Errorf("expected no warning, got %q", logged)
+				}
+				return
+			}
+			if !strings.Contains(logged, "[WARN]") || !strings.Contains(logged, testCase.warning) {
+				t.Errorf("expected a warning containing %q, got %q", testCase.warning, logged)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+const domainsKey = "domains"
+const verifiedMethodsKey = "verified_methods"
+const domainTimeoutKey = "domain_timeout"
+const failedDomainsKey = "failed_domains"
+
+// dnsVerificationMethods are the verification methods relying on a DNS record.
+var dnsVerificationMethods = []string{"DNS_TXT", "DNS_CNAME"}
+
+func dnsDomainsSiteVerificationResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainsKey: {
+				Type:        schema.TypeMap,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains you want to verify, mapped to the verification method to use for each of them (`DNS_TXT` or `DNS_CNAME`). The matching DNS records must already exist.",
+			},
+			verifiedMethodsKey: {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains which are currently verified, mapped to the method they were verified with.",
+			},
+			domainTimeoutKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+				Description:  "How long to keep trying to verify each domain, e.g. `5m`. A domain which is still not verified after that is reported as failed, and the next ones are tried. Defaults to the whole create or update timeout.",
+			},
+			failedDomainsKey: {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains which could not be verified or unverified during the last apply, mapped to the error. Check it after the creation, which does not fail when some domains do, as the resource would then be replaced, unverifying the others.",
+			},
+		},
+		Create:        createDnsDomainsSiteVerification,
+		Read:          readDnsDomainsSiteVerification,
+		Update:        updateDnsDomainsSiteVerification,
+		Delete:        deleteDnsDomainsSiteVerification,
+		CustomizeDiff: customizeDnsDomainsDiff,
+		Description:   "Verifies several domains at once, each with its own DNS verification method. Errors are reported for all the domains at once, and the domains which could be verified are kept in the state. The creation only logs the errors, in `failed_domains` and as warnings, and the next apply tries the failed domains again as an update.",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+// validateDomainMethods checks that the domains are mapped to DNS verification methods.
+func validateDomainMethods(domainMethods map[string]interface{}) error {
+	var errs *multierror.Error
+	for _, domain := range sortedKeys(domainMethods) {
+		_, methodErrs := validation.StringInSlice(dnsVerificationMethods, false)(domainMethods[domain], fmt.Sprintf("%s[%q]", domainsKey, domain))
+		errs = multierror.Append(errs, methodErrs...)
+	}
+	return errs.ErrorOrNil()
+}
+
+// customizeDnsDomainsDiff marks the outcome of the verifications as unknown whenever the domains change,
+// including when they are only known after apply, so the plan does not promise the previous outcome.
+func customizeDnsDomainsDiff(diff *schema.ResourceDiff, provider interface{}) error {
+	// the methods only known after apply are checked then, by the API
+	if diff.NewValueKnown(domainsKey) {
+		if methodsErr := validateDomainMethods(diff.Get(domainsKey).(map[string]interface{})); methodsErr != nil {
+			return methodsErr
+		}
+	}
+	if !diff.HasChange(domainsKey) && diff.NewValueKnown(domainsKey) {
+		return nil
+	}
+	if setErr := diff.SetNewComputed(verifiedMethodsKey); setErr != nil {
+		return setErr
+	}
+	return diff.SetNewComputed(failedDomainsKey)
+}
+
+func createDnsDomainsSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	resourceData.SetId(resource.UniqueId())
+
+	verifiedMethods := map[string]interface{}{}
+	failures := syncDnsDomains(provider.(configuredProvider), resourceData.Timeout(schema.TimeoutCreate), domainTimeout(resourceData), verifiedMethods, resourceData.Get(domainsKey).(map[string]interface{}))
+
+	// whatever got verified must be kept in the state, so it can be deleted later
+	if setErr := setDnsDomainsSyncResult(resourceData, verifiedMethods, failures); setErr != nil {
+		return setErr
+	}
+	if len(failures) > 0 {
+		// failing would taint the resource, whose replacement would unverify the domains which succeeded:
+		// the failed domains are in failed_domains instead, and the next plan updates the resource to try them again
+		log.Printf("[WARN] some domains of %s could not be verified, they will be tried again on the next apply: %s", resourceData.Id(), batchErr(failures))
+	}
+	return nil
+}
+
+func updateDnsDomainsSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	verifiedMethods := resourceData.Get(verifiedMethodsKey).(map[string]interface{})
+	failures := syncDnsDomains(provider.(configuredProvider), resourceData.Timeout(schema.TimeoutUpdate), domainTimeout(resourceData), verifiedMethods, resourceData.Get(domainsKey).(map[string]interface{}))
+
+	if setErr := setDnsDomainsSyncResult(resourceData, verifiedMethods, failures); setErr != nil {
+		return setErr
+	}
+	return batchErr(failures)
+}
+
+func readDnsDomainsSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	verifiedMethods := resourceData.Get(verifiedMethodsKey).(map[string]interface{})
+
+	var readErr *multierror.Error
+	for domain := range verifiedMethods {
+		_, getErr := getWebResource(provider.(configuredProvider), webResourceID(domain))
+		if getErr != nil {
+			if httpStatusCode(getErr) == http.StatusNotFound {
+				// unverified out of band: it will be verified again on the next apply
+				delete(verifiedMethods, domain)
+				continue
+			}
+			readErr = multierror.Append(readErr, fmt.Errorf("%s: %w", domain, getErr))
+		}
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	if setErr := resourceData.Set(verifiedMethodsKey, verifiedMethods); setErr != nil {
+		return setErr
+	}
+	// reflecting what is actually verified lets Terraform plan the verification of what is missing
+	return resourceData.Set(domainsKey, verifiedMethods)
+}
+
+func deleteDnsDomainsSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	verifiedMethods := resourceData.Get(verifiedMethodsKey).(map[string]interface{})
+	failures := syncDnsDomains(provider.(configuredProvider), resourceData.Timeout(schema.TimeoutDelete), domainTimeout(resourceData), verifiedMethods, map[string]interface{}{})
+
+	if setErr := setDnsDomainsSyncResult(resourceData, verifiedMethods, failures); setErr != nil {
+		return setErr
+	}
+	return batchErr(failures)
+}
+
+// domainTimeout returns the configured timeout for each domain, or 0 if there is none.
+func domainTimeout(resourceData *schema.ResourceData) time.Duration {
+	// the value has already been validated by the schema
+	timeout, _ := time.ParseDuration(resourceData.Get(domainTimeoutKey).(string))
+	return timeout
+}
+
+// setDnsDomainsSyncResult records the outcome of syncDnsDomains in the state.
+func setDnsDomainsSyncResult(resourceData *schema.ResourceData, verifiedMethods map[string]interface{}, failures map[string]error) error {
+	if setErr := resourceData.Set(verifiedMethodsKey, verifiedMethods); setErr != nil {
+		return setErr
+	}
+
+	failedDomains := make(map[string]interface{}, len(failures))
+	for domain, failure := range failures {
+		failedDomains[domain] = failure.Error()
+	}
+	return resourceData.Set(failedDomainsKey, failedDomains)
+}
+
+// syncDnsDomains verifies and unverifies domains until verifiedMethods matches the wanted domains,
+// and returns the errors of the domains which failed.
+// verifiedMethods is updated as it goes, so it stays accurate when some domains fail.
+// Each domain is given at most domainTimeout, if not 0, out of the overall timeout.
+func syncDnsDomains(provider configuredProvider, timeout time.Duration, domainTimeout time.Duration, verifiedMethods map[string]interface{}, wanted map[string]interface{}) map[string]error {
+	deadline := time.Now().Add(timeout)
+	timeoutFor := func() time.Duration {
+		remaining := time.Until(deadline)
+		if domainTimeout > 0 && domainTimeout < remaining {
+			return domainTimeout
+		}
+		return remaining
+	}
+
+	failures := map[string]error{}
+
+	for _, domain := range sortedKeys(verifiedMethods) {
+		if _, isWanted := wanted[domain]; isWanted {
+			continue
+		}
+		if deleteErr := deleteSiteVerification(provider, timeoutFor(), webResourceID(domain)); deleteErr != nil {
+			failures[domain] = deleteErr
+			continue
+		}
+		delete(verifiedMethods, domain)
+	}
+
+	for _, domain := range sortedKeys(wanted) {
+		method := wanted[domain].(string)
+		if verifiedMethods[domain] == method {
+			continue
+		}
+		if _, insertErr := insertSiteVerification(provider, timeoutFor(), domain, method); insertErr != nil {
+			failures[domain] = insertErr
+			continue
+		}
+		verifiedMethods[domain] = method
+	}
+
+	return failures
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"github.com/zclconf/go-cty/cty"
+	"google.golang.org/api/siteverification/v1"
+)
+
+func TestDnsDomainsUnknownAtPlanTime(t *testing.T) {
+	testCases := []struct {
+		name    string
+		domains cty.Value
+	}{
+		{name: "unknown map", domains: cty.UnknownVal(cty.Map(cty.String))},
+		{name: "unknown method", domains: cty.MapVal(map[string]cty.Value{"example.com": cty.StringVal("DNS_TXT"), "example.org": cty.UnknownVal(cty.String)})},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resource := dnsDomainsSiteVerificationResource()
+			config := terraform.NewResourceConfigShimmed(cty.ObjectVal(map[string]cty.Value{domainsKey: testCase.domains}), resource.CoreConfigSchema())
+
+			if _, errs := resource.Validate(config); len(errs) > 0 {
+				t.Fatalf("expected the configuration to be valid, got %v", errs)
+			}
+
+			state := &terraform.InstanceState{
+				ID: "existing",
+				Attributes: map[string]string{
+					"id":                                "existing",
+					domainsKey + ".%":                   "1",
+					domainsKey + ".example.com":         "DNS_TXT",
+					verifiedMethodsKey + ".%":           "1",
+					verifiedMethodsKey + ".example.com": "DNS_TXT",
+					failedDomainsKey + ".%":             "0",
+				},
+			}
+			diff, diffErr := resource.Diff(state, config, nil)
+			if diffErr != nil {
+				t.Fatal(diffErr)
+			}
+			for _, key := range []string{verifiedMethodsKey + ".%", failedDomainsKey + ".%"} {
+				if attribute := diff.Attributes[key]; attribute == nil || !attribute.NewComputed {
+					t.Errorf("expected %s to be unknown until apply, got %#v", key, attribute)
+				}
+			}
+		})
+	}
+}
+
+func TestDnsDomainsInvalidMethod(t *testing.T) {
+	resource := dnsDomainsSiteVerificationResource()
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{domainsKey: map[string]interface{}{"example.com": "DNS_TXT", "example.org": "META"}})
+
+	if _, diffErr := resource.Diff(nil, config, nil); diffErr == nil || !strings.Contains(diffErr.Error(), "example.org") {
+		t.Errorf("expected the method of example.org to be refused, got %v", diffErr)
+	}
+}
+
+func TestCreateDnsDomainsKeepsPartialResult(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var webResource siteverification.SiteVerificationWebResourceResource
+		_ = json.NewDecoder(r.Body).Decode(&webResource)
+		if webResource.Site.Identifier == "b.example.com" {
+			writeAPIError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id": "dns%%3A%%2F%%2F%s", "site": {"identifier": %q, "type": "INET_DOMAIN"}}`, webResource.Site.Identifier, webResource.Site.Identifier)
+	})
+
+	resourceData := schema.TestResourceDataRaw(t, dnsDomainsSiteVerificationResource().Schema, map[string]interface{}{
+		domainsKey: map[string]interface{}{"a.example.com": "DNS_TXT", "b.example.com": "DNS_TXT"},
+	})
+	if createErr := createDnsDomainsSiteVerification(resourceData, provider); createErr != nil {
+		t.Fatalf("expected the creation not to fail, which would taint the resource, got %v", createErr)
+	}
+	if verifiedMethods := resourceData.Get(verifiedMethodsKey).(map[string]interface{}); len(verifiedMethods) != 1 || verifiedMethods["a.example.com"] != "DNS_TXT" {
+		t.Errorf("expected a.example.com to be verified, got %v", verifiedMethods)
+	}
+	if failedDomains := resourceData.Get(failedDomainsKey).(map[string]interface{}); len(failedDomains) != 1 || failedDomains["b.example.com"] == nil {
+		t.Errorf("expected b.example.com to have failed, got %v", failedDomains)
+	}
+}
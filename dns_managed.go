@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"google.golang.org/api/dns/v1"
+)
+
+const projectKey = "project"
+const managedZoneKey = "managed_zone"
+const ttlKey = "ttl"
+
+func dnsManagedSiteVerificationResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainKey: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The domain you want to verify, e.g. `example.com`. It must be in the managed zone.",
+			},
+			projectKey: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The Google Cloud project of the Cloud DNS managed zone.",
+			},
+			managedZoneKey: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The name of the Cloud DNS managed zone serving the domain, e.g. the `name` of a google_dns_managed_zone.",
+			},
+			ttlKey: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      300,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "The TTL of the TXT record, when the provider creates it.",
+			},
+			tokenKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The `DNS_TXT` token the domain was verified with.",
+			},
+			recordNameKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The fully qualified name of the TXT record holding the token.",
+			},
+		},
+		Create:      createDnsManagedSiteVerification,
+		Read:        readDnsManagedSiteVerification,
+		Delete:      deleteDnsManagedSiteVerification,
+		Description: "https://cloud.google.com/dns\n\nVerifies a domain served by Cloud DNS from start to end: gets its `DNS_TXT` token, adds it to the TXT records of the domain in the managed zone, keeping the other values, and verifies the domain. On destroy, the token is removed from the records and the domain unverified, in that order since Google refuses to unverify a domain while it still sees the token. The refresh checks that the token is still in the records, planning to create them again otherwise, and a failed verification removes the token it added. The credentials need the `roles/dns.admin` role on the project, or the `dns.changes.*` and `dns.resourceRecordSets.*` permissions, and are requested the `ndev.clouddns.readwrite` scope for this resource only; without them, use data.googlesiteverification_dns_token and the `googlesiteverification_dns` resource with the DNS provider of your choice.",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
+	}
+}
+
+func createDnsManagedSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	domain := bareDomain(resourceData.Get(domainKey).(string))
+	project := resourceData.Get(projectKey).(string)
+	zone := resourceData.Get(managedZoneKey).(string)
+
+	start := time.Now()
+	timeout := resourceData.Timeout(schema.TimeoutCreate)
+
+	token, getTokenErr := getToken(provider.(configuredProvider), domain, "DNS_TXT")
+	if getTokenErr != nil {
+		return getTokenErr
+	}
+	name := fullyQualified(domain)
+	if addErr := addTXTValue(provider.(configuredProvider), project, zone, name, token, int64(resourceData.Get(ttlKey).(int)), timeout); addErr != nil {
+		return cloudDNSErr(project, zone, addErr)
+	}
+	if setErr := resourceData.Set(tokenKey, token); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(recordNameKey, name); setErr != nil {
+		return setErr
+	}
+
+	id, insertErr := insertSiteVerification(provider.(configuredProvider), timeout-time.Since(start), domain, "DNS_TXT")
+	if insertErr != nil {
+		// nothing is in the state for a destroy to clean up, so the record would be left behind
+		if removeErr := removeTXTValue(provider.(configuredProvider), project, zone, name, token, timeout-time.Since(start)); removeErr != nil {
+			log.Printf("[WARN] could not remove the token of %s from the TXT records of %s: %s", domain, name, removeErr)
+		}
+		return insertErr
+	}
+	resourceData.SetId(id)
+
+	return readDnsManagedSiteVerification(resourceData, provider)
+}
+
+func readDnsManagedSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	_, getErr := getWebResource(provider.(configuredProvider), resourceData.Id())
+	if getErr != nil {
+		if httpStatusCode(getErr) == http.StatusNotFound {
+			// unverified out of band: Terraform will plan to verify it again
+			log.Printf("[WARN] %s is not verified anymore, removing it from the state", resourceData.Id())
+			resourceData.SetId("")
+			return nil
+		}
+		return getErr
+	}
+
+	project := resourceData.Get(projectKey).(string)
+	zone := resourceData.Get(managedZoneKey).(string)
+	name := resourceData.Get(recordNameKey).(string)
+	recordSet, findErr := findTXTRecordSet(provider.(configuredProvider), project, zone, name)
+	if findErr != nil {
+		return cloudDNSErr(project, zone, findErr)
+	}
+	if !hasTXTValue(recordSet, resourceData.Get(tokenKey).(string)) {
+		// removed out of band: Google will unverify the domain once it notices, so Terraform must create it again
+		log.Printf("[WARN] the token of %s is not in the TXT records of %s anymore, removing it from the state", resourceData.Id(), name)
+		resourceData.SetId("")
+	}
+	return nil
+}
+
+func deleteDnsManagedSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	project := resourceData.Get(projectKey).(string)
+	zone := resourceData.Get(managedZoneKey).(string)
+
+	start := time.Now()
+	timeout := resourceData.Timeout(schema.TimeoutDelete)
+
+	removeErr := removeTXTValue(provider.(configuredProvider), project, zone, resourceData.Get(recordNameKey).(string), resourceData.Get(tokenKey).(string), timeout)
+	if removeErr != nil {
+		if httpStatusCode(removeErr) != http.StatusNotFound {
+			return cloudDNSErr(project, zone, removeErr)
+		}
+		log.Printf("[WARN] the managed zone %s of %s is gone, so is the record of %s", zone, project, resourceData.Id())
+	}
+
+	return deleteSiteVerification(provider.(configuredProvider), timeout-time.Since(start), resourceData.Id())
+}
+
+// cloudDNSErr explains the Cloud DNS errors due to missing permissions, which only googlesiteverification_dns_managed needs.
+func cloudDNSErr(project string, zone string, err error) error {
+	switch httpStatusCode(err) {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("the credentials can't manage the records of the Cloud DNS managed zone %s of the project %s: they need the roles/dns.admin role and the %s scope, or the record must be created by other means and the domain verified with googlesiteverification_dns: %w", zone, project, dns.NdevClouddnsReadwriteScope, err)
+	default:
+		return err
+	}
+}
+
+// fullyQualified returns the name as Cloud DNS expects it, ending with a dot.
+func fullyQualified(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
+
+// txtRRData returns the record data of a TXT value, split into quoted character-strings.
+func txtRRData(value string) string {
+	chunks := txtCharacterStrings(value)
+	for i, chunk := range chunks {
+		chunks[i] = fmt.Sprintf("%q", chunk)
+	}
+	return strings.Join(chunks, " ")
+}
+
+// txtValue returns the value held by the record data of a TXT record, its character-strings joined.
+func txtValue(rrdata string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(rrdata, `" "`, ""), `"`, "")
+}
+
+// findTXTRecordSet returns the TXT records of the name, or nil if there are none.
+func findTXTRecordSet(provider configuredProvider, project string, zone string, name string) (*dns.ResourceRecordSet, error) {
+	service, serviceErr := provider.cloudDNS()
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	var recordSets *dns.ResourceRecordSetsListResponse
+	listErr := provider.backoff.retryableAPICall(fmt.Sprintf("the listing of the TXT records of %s", name), func() error {
+		var err error
+		ctx, cancel := provider.requestContext()
+		defer cancel()
+		recordSets, err = service.ResourceRecordSets.List(project, zone).Name(name).Type("TXT").Context(ctx).Do()
+		return err
+	})
+	if listErr != nil {
+		return nil, listErr
+	}
+	for _, recordSet := range recordSets.Rrsets {
+		if recordSet.Name == name && recordSet.Type == "TXT" {
+			return recordSet, nil
+		}
+	}
+	return nil, nil
+}
+
+// hasTXTValue tells whether the TXT records hold the value, false if there are none.
+func hasTXTValue(recordSet *dns.ResourceRecordSet, value string) bool {
+	if recordSet == nil {
+		return false
+	}
+	for _, rrdata := range recordSet.Rrdatas {
+		if txtValue(rrdata) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// addTXTValue adds the value to the TXT records of the name, keeping their other values, unless it is already there.
+func addTXTValue(provider configuredProvider, project string, zone string, name string, value string, ttl int64, timeout time.Duration) error {
+	existing, findErr := findTXTRecordSet(provider, project, zone, name)
+	if findErr != nil {
+		return findErr
+	}
+
+	change := &dns.Change{}
+	if existing == nil {
+		change.Additions = []*dns.ResourceRecordSet{{Name: name, Type: "TXT", Ttl: ttl, Rrdatas: []string{txtRRData(value)}}}
+	} else {
+		if hasTXTValue(existing, value) {
+			return nil
+		}
+		updated := *existing
+		updated.Rrdatas = append(append([]string{}, existing.Rrdatas...), txtRRData(value))
+		change.Deletions = []*dns.ResourceRecordSet{existing}
+		change.Additions = []*dns.ResourceRecordSet{&updated}
+	}
+	return applyDNSChange(provider, project, zone, change, timeout)
+}
+
+// removeTXTValue removes the value from the TXT records of the name, keeping their other values.
+func removeTXTValue(provider configuredProvider, project string, zone string, name string, value string, timeout time.Duration) error {
+	existing, findErr := findTXTRecordSet(provider, project, zone, name)
+	if findErr != nil || existing == nil {
+		return findErr
+	}
+
+	var remaining []string
+	for _, rrdata := range existing.Rrdatas {
+		if txtValue(rrdata) != value {
+			remaining = append(remaining, rrdata)
+		}
+	}
+	if len(remaining) == len(existing.Rrdatas) {
+		// removed out of band
+		return nil
+	}
+
+	change := &dns.Change{Deletions: []*dns.ResourceRecordSet{existing}}
+	if len(remaining) > 0 {
+		updated := *existing
+		updated.Rrdatas = remaining
+		change.Additions = []*dns.ResourceRecordSet{&updated}
+	}
+	return applyDNSChange(provider, project, zone, change, timeout)
+}
+
+// applyDNSChange submits the change to the managed zone and waits for Cloud DNS to have applied it.
+func applyDNSChange(provider configuredProvider, project string, zone string, change *dns.Change, timeout time.Duration) error {
+	if writableErr := provider.checkWritable(fmt.Sprintf("change the records of the managed zone %s", zone)); writableErr != nil {
+		return writableErr
+	}
+
+	service, serviceErr := provider.cloudDNS()
+	if serviceErr != nil {
+		return serviceErr
+	}
+
+	var applied *dns.Change
+	createErr := provider.backoff.retryableAPICall(fmt.Sprintf("the change of the managed zone %s", zone), func() error {
+		var err error
+		ctx, cancel := provider.requestContext()
+		defer cancel()
+		applied, err = service.Changes.Create(project, zone, change).Context(ctx).Do()
+		return err
+	})
+	if createErr != nil {
+		return createErr
+	}
+
+	return provider.backoff.retry(timeout, func() *resource.RetryError {
+		if applied.Status == "done" {
+			return nil
+		}
+		ctx, cancel := provider.requestContext()
+		defer cancel()
+		current, getErr := service.Changes.Get(project, zone, applied.Id).Context(ctx).Do()
+		if getErr != nil {
+			if transientStatusCodes[httpStatusCode(getErr)] {
+				return resource.RetryableError(getErr)
+			}
+			return resource.NonRetryableError(getErr)
+		}
+		applied = current
+		if applied.Status != "done" {
+			return resource.RetryableError(fmt.Errorf("the change %s of the managed zone %s is still %s", applied.Id, zone, applied.Status))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/dns/v1"
+	"google.golang.org/api/option"
+)
+
+// newTestCloudDNS returns a Cloud DNS client whose managed zones hold the TXT records of example.com. in records.
+func newTestCloudDNS(t *testing.T, records *[]string) func() (*dns.Service, error) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/rrsets"):
+			var rrsets []*dns.ResourceRecordSet
+			if len(*records) > 0 {
+				rrsets = append(rrsets, &dns.ResourceRecordSet{Name: "example.com.", Type: "TXT", Ttl: 300, Rrdatas: *records})
+			}
+			_ = json.NewEncoder(w).Encode(dns.ResourceRecordSetsListResponse{Rrsets: rrsets})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/changes"):
+			var change dns.Change
+			if decodeErr := json.NewDecoder(r.Body).Decode(&change); decodeErr != nil {
+				t.Fatal(decodeErr)
+			}
+			*records = nil
+			for _, addition := range change.Additions {
+				*records = append(*records, addition.Rrdatas...)
+			}
+			change.Id = "1"
+			change.Status = "done"
+			_ = json.NewEncoder(w).Encode(change)
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cloudDNS, serviceErr := dns.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	if serviceErr != nil {
+		t.Fatal(serviceErr)
+	}
+	return func() (*dns.Service, error) { return cloudDNS, nil }
+}
+
+func TestAddRemoveTXTValue(t *testing.T) {
+	records := []string{`"v=spf1 -all"`}
+	provider := configuredProvider{cloudDNS: newTestCloudDNS(t, &records), backoff: backoff{baseDelay: time.Millisecond, multiplier: 1}}
+
+	if addErr := addTXTValue(provider, "project", "zone", "example.com.", "google-site-verification=abc", 300, time.Second); addErr != nil {
+		t.Fatal(addErr)
+	}
+	if expected := []string{`"v=spf1 -all"`, `"google-site-verification=abc"`}; !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected the token to be added to the existing records %q, got %q", expected, records)
+	}
+
+	if removeErr := removeTXTValue(provider, "project", "zone", "example.com.", "google-site-verification=abc", time.Second); removeErr != nil {
+		t.Fatal(removeErr)
+	}
+	if expected := []string{`"v=spf1 -all"`}; !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected only the token to be removed, leaving %q, got %q", expected, records)
+	}
+}
+
+func TestCloudDNSErr(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusForbidden, "Forbidden")
+	})
+	_, getErr := provider.service.WebResource.Get("dns://example.com").Do()
+	err := cloudDNSErr("project", "zone", getErr)
+	if !strings.Contains(err.Error(), "roles/dns.admin") {
+		t.Errorf("expected the missing permissions to be explained, got %q", err)
+	}
+}
+
+func TestCreateDnsManagedRemovesRecordOnFailure(t *testing.T) {
+	records := []string{`"v=spf1 -all"`}
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/token") {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, `{"method": "DNS_TXT", "token": "google-site-verification=abc"}`)
+			return
+		}
+		writeAPIError(w, http.StatusUnauthorized, "Unauthorized")
+	})
+	provider.cloudDNS = newTestCloudDNS(t, &records)
+
+	resourceData := schema.TestResourceDataRaw(t, dnsManagedSiteVerificationResource().Schema, map[string]interface{}{
+		domainKey:      "Example.com",
+		projectKey:     "project",
+		managedZoneKey: "zone",
+	})
+	if createErr := createDnsManagedSiteVerification(resourceData, provider); createErr == nil {
+		t.Fatal("expected the failed verification to be reported")
+	}
+	if name := resourceData.Get(recordNameKey).(string); name != "example.com." {
+		t.Errorf("expected the record of the lowercase domain, got %q", name)
+	}
+	if resourceData.Id() != "" {
+		t.Errorf("expected nothing in the state, got the id %s", resourceData.Id())
+	}
+	if expected := []string{`"v=spf1 -all"`}; !reflect.DeepEqual(records, expected) {
+		t.Errorf("expected the token to be removed from the records, leaving %q, got %q", expected, records)
+	}
+}
+
+func TestReadDnsManagedChecksRecord(t *testing.T) {
+	for _, recordExists := range []bool{true, false} {
+		t.Run(fmt.Sprintf("record exists %t", recordExists), func(t *testing.T) {
+			records := []string{`"v=spf1 -all"`}
+			if recordExists {
+				records = append(records, `"google-site-verification=abc"`)
+			}
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "site": {"identifier": "example.com", "type": "INET_DOMAIN"}}`)
+			})
+			provider.cloudDNS = newTestCloudDNS(t, &records)
+
+			resourceData := schema.TestResourceDataRaw(t, dnsManagedSiteVerificationResource().Schema, map[string]interface{}{
+				domainKey:      "example.com",
+				projectKey:     "project",
+				managedZoneKey: "zone",
+			})
+			resourceData.SetId("dns://example.com")
+			for key, value := range map[string]string{tokenKey: "google-site-verification=abc", recordNameKey: "example.com."} {
+				if setErr := resourceData.Set(key, value); setErr != nil {
+					t.Fatal(setErr)
+				}
+			}
+			if readErr := readDnsManagedSiteVerification(resourceData, provider); readErr != nil {
+				t.Fatal(readErr)
+			}
+			if (resourceData.Id() != "") != recordExists {
+				t.Errorf("expected the resource to be kept in the state only if its record exists, got the id %q", resourceData.Id())
+			}
+		})
+	}
+}
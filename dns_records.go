@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+const methodsKey = "methods"
+const recordsKey = "records"
+const methodKey = "method"
+const typeKey = "type"
+const nameKey = "name"
+const valueKey = "value"
+const recordValueStringsKey = "record_value_strings"
+
+// maxTXTStringLength is the maximum length, in bytes, of a single character-string in a TXT record (RFC 1035).
+const maxTXTStringLength = 255
+
+// dnsRecord is a DNS record Google expects to find before verifying a domain.
+type dnsRecord struct {
+	recordType string
+	name       string
+	value      string
+}
+
+// dnsRecordFromToken builds the DNS record to create for a token obtained with the given method.
+func dnsRecordFromToken(domain string, method string, token string) (dnsRecord, error) {
+	switch method {
+	case "DNS_TXT":
+		return dnsRecord{recordType: "TXT", name: domain, value: token}, nil
+	case "DNS_CNAME":
+		// the token holds the whole record, e.g. "abc123.example.com CNAME gv-xyz.dv.googlehosted.com"
+		fields := strings.Fields(token)
+		if len(fields) == 3 && strings.EqualFold(fields[1], "CNAME") {
+			fields = []string{fields[0], fields[2]}
+		}
+		if len(fields) != 2 {
+			return dnsRecord{}, fmt.Errorf("unexpected DNS_CNAME token format %q", token)
+		}
+		return dnsRecord{
+			recordType: "CNAME",
+			name:       strings.TrimSuffix(fields[0], "."),
+			value:      strings.TrimSuffix(fields[1], "."),
+		}, nil
+	default:
+		return dnsRecord{}, fmt.Errorf("%s is not a DNS verification method", method)
+	}
+}
+
+// txtCharacterStrings splits a TXT record value into character-strings of at most maxTXTStringLength bytes.
+func txtCharacterStrings(value string) []string {
+	chunks := make([]string, 0, len(value)/maxTXTStringLength+1)
+	for len(value) > maxTXTStringLength {
+		chunks = append(chunks, value[:maxTXTStringLength])
+		value = value[maxTXTStringLength:]
+	}
+	return append(chunks, value)
+}
+
+func dnsRecordsDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainKey: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The domain you want to verify.",
+			},
+			methodsKey: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringInSlice(dnsVerificationMethods, false)},
+				Description: "The DNS verification methods you want records for. Defaults to all of them (`DNS_TXT` and `DNS_CNAME`).",
+			},
+			recordsKey: {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						methodKey: {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The verification method this record is for.",
+						},
+						typeKey: {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The type of DNS record you should create.",
+						},
+						nameKey: {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the record you should create.",
+						},
+						valueKey: {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The value of the record you should create.",
+						},
+					},
+				},
+				Description: "The DNS records you should create, one per method.",
+			},
+		},
+		Description: "Returns the DNS records needed to verify a domain with several methods at once.",
+		Read:        readDnsRecords,
+	}
+}
+
+func readDnsRecords(resourceData *schema.ResourceData, provider interface{}) error {
+	domain := resourceData.Get(domainKey).(string)
+
+	methods := dnsVerificationMethods
+	if configuredMethods := resourceData.Get(methodsKey).([]interface{}); len(configuredMethods) > 0 {
+		methods = make([]string, len(configuredMethods))
+		for i, method := range configuredMethods {
+			methods[i] = method.(string)
+		}
+	}
+
+	records := make([]map[string]interface{}, 0, len(methods))
+	for _, method := range methods {
+		token, getTokenErr := getToken(provider.(configuredProvider), domain, method)
+		if getTokenErr != nil {
+			return fmt.Errorf("getting the %s token: %w", method, getTokenErr)
+		}
+
+		record, recordErr := dnsRecordFromToken(domain, method, token)
+		if recordErr != nil {
+			return recordErr
+		}
+
+		records = append(records, map[string]interface{}{
+			methodKey: method,
+			typeKey:   record.recordType,
+			nameKey:   record.name,
+			valueKey:  record.value,
+		})
+	}
+
+	if setErr := resourceData.Set(recordsKey, records); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId(domain)
+
+	return nil
+}
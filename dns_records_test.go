@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTXTCharacterStrings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{
+			name:     "short",
+			value:    "google-site-verification=abc",
+			expected: []string{"google-site-verification=abc"},
+		},
+		{
+			name:     "exactly 255 bytes",
+			value:    strings.Repeat("a", 255),
+			expected: []string{strings.Repeat("a", 255)},
+		},
+		{
+			name:     "256 bytes",
+			value:    strings.Repeat("a", 255) + "b",
+			expected: []string{strings.Repeat("a", 255), "b"},
+		},
+		{
+			name:     "several strings",
+			value:    strings.Repeat("a", 255) + strings.Repeat("b", 255) + "cd",
+			expected: []string{strings.Repeat("a", 255), strings.Repeat("b", 255), "cd"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := txtCharacterStrings(testCase.value)
+			if !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+			if joined := strings.Join(actual, ""); joined != testCase.value {
+				t.Errorf("expected the strings to join back into %q, got %q", testCase.value, joined)
+			}
+		})
+	}
+}
+
+func TestDNSRecordFromToken(t *testing.T) {
+	testCases := []struct {
+		name     string
+		method   string
+		token    string
+		expected dnsRecord
+		valid    bool
+	}{
+		{
+			name:     "TXT",
+			method:   "DNS_TXT",
+			token:    "google-site-verification=abc",
+			expected: dnsRecord{recordType: "TXT", name: "example.com", value: "google-site-verification=abc"},
+			valid:    true,
+		},
+		{
+			name:     "CNAME with its type",
+			method:   "DNS_CNAME",
+			token:    "abc123.example.com CNAME gv-xyz.dv.googlehosted.com",
+			expected: dnsRecord{recordType: "CNAME", name: "abc123.example.com", value: "gv-xyz.dv.googlehosted.com"},
+			valid:    true,
+		},
+		{
+			name:     "CNAME without its type",
+			method:   "DNS_CNAME",
+			token:    "abc123.example.com gv-xyz.dv.googlehosted.com",
+			expected: dnsRecord{recordType: "CNAME", name: "abc123.example.com", value: "gv-xyz.dv.googlehosted.com"},
+			valid:    true,
+		},
+		{
+			name:     "CNAME with extra whitespace and trailing dots",
+			method:   "DNS_CNAME",
+			token:    "  abc123.example.com.\tcname   gv-xyz.dv.googlehosted.com.\n",
+			expected: dnsRecord{recordType: "CNAME", name: "abc123.example.com", value: "gv-xyz.dv.googlehosted.com"},
+			valid:    true,
+		},
+		{
+			name:   "CNAME with a single field",
+			method: "DNS_CNAME",
+			token:  "gv-xyz.dv.googlehosted.com",
+			valid:  false,
+		},
+		{
+			name:   "not a DNS method",
+			method: "META",
+			token:  "<meta>",
+			valid:  false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			record, err := dnsRecordFromToken("example.com", testCase.method, testCase.token)
+			if (err == nil) != testCase.valid {
+				t.Fatalf("expected valid=%t, got %v", testCase.valid, err)
+			}
+			if record != testCase.expected {
+				t.Errorf("expected %+v, got %+v", testCase.expected, record)
+			}
+		})
+	}
+}
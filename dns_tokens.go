@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+const tokensKey = "tokens"
+const concurrencyKey = "concurrency"
+const rateLimitKey = "rate_limit"
+
+func dnsTokensDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainsKey: {
+				Type:        schema.TypeSet,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains you want to verify.",
+			},
+			methodKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "DNS_TXT",
+				ValidateFunc: validation.StringInSlice(dnsVerificationMethods, false),
+				Description:  "The DNS verification method to get tokens for.",
+			},
+			concurrencyKey: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      4,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "How many tokens to request at the same time.",
+			},
+			rateLimitKey: {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Default:      0.0,
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "How many tokens to request per second at most, to stay within the API quota. Unlimited if 0. The rates below one token per hour are rounded up to it.",
+			},
+			tokensKey: {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The tokens, by domain.",
+			},
+		},
+		Description: "https://developers.google.com/site-verification/v1/webResource/getToken\n\nGets the tokens of many domains at once, concurrently.",
+		Read:        readDnsTokens,
+	}
+}
+
+func readDnsTokens(resourceData *schema.ResourceData, provider interface{}) error {
+	method := resourceData.Get(methodKey).(string)
+
+	var domains []string
+	for _, domain := range resourceData.Get(domainsKey).(*schema.Set).List() {
+		domains = append(domains, domain.(string))
+	}
+	sort.Strings(domains)
+
+	limiter := newRateLimiter(resourceData.Get(rateLimitKey).(float64))
+	defer limiter.stop()
+
+	var tokensMu sync.Mutex
+	tokens := make(map[string]interface{}, len(domains))
+	failures := forEachConcurrently(domains, resourceData.Get(concurrencyKey).(int), limiter, func(domain string) error {
+		token, getTokenErr := getToken(provider.(configuredProvider), domain, method)
+		if getTokenErr != nil {
+			return getTokenErr
+		}
+
+		tokensMu.Lock()
+		defer tokensMu.Unlock()
+		tokens[domain] = token
+		return nil
+	})
+
+	if len(failures) > 0 {
+		var tokensErr *multierror.Error
+		for _, domain := range domains {
+			if failure, failed := failures[domain]; failed {
+				tokensErr = multierror.Append(tokensErr, fmt.Errorf("%s: %w", domain, failure))
+			}
+		}
+		return tokensErr
+	}
+
+	if setErr := resourceData.Set(tokensKey, tokens); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId(strconv.Itoa(hashcode.String(method + ":" + strings.Join(domains, ","))))
+
+	return nil
+}
+
+// forEachConcurrently calls f for each item, running at most concurrency calls at once
+// and waiting for the limiter before each call. It returns the errors by item.
+func forEachConcurrently(items []string, concurrency int, limiter *rateLimiter, f func(string) error) map[string]error {
+	var wg sync.WaitGroup
+	var failuresMu sync.Mutex
+	failures := map[string]error{}
+
+	semaphore := make(chan struct{}, concurrency)
+	for _, item := range items {
+		semaphore <- struct{}{}
+		limiter.wait()
+
+		wg.Add(1)
+		go func(item string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if err := f(item); err != nil {
+				failuresMu.Lock()
+				defer failuresMu.Unlock()
+				failures[item] = err
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	return failures
+}
+
+// maxRateLimitInterval is the longest wait between two calls a rateLimiter makes, whatever its rate.
+// It keeps the tiny rates from overflowing the interval of the ticker.
+const maxRateLimitInterval = time.Hour
+
+// rateLimiter lets calls through at a steady rate. A nil rateLimiter does not limit anything.
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+// newRateLimiter returns a rateLimiter letting callsPerSecond calls through every second, but at least one every
+// maxRateLimitInterval, or nil if callsPerSecond is 0.
+func newRateLimiter(callsPerSecond float64) *rateLimiter {
+	if callsPerSecond <= 0 {
+		return nil
+	}
+	interval := maxRateLimitInterval
+	if seconds := 1 / callsPerSecond; seconds < maxRateLimitInterval.Seconds() {
+		interval = time.Duration(seconds * float64(time.Second))
+	}
+	return &rateLimiter{ticker: time.NewTicker(interval)}
+}
+
+// wait blocks until the next call is allowed.
+func (limiter *rateLimiter) wait() {
+	if limiter != nil {
+		<-limiter.ticker.C
+	}
+}
+
+func (limiter *rateLimiter) stop() {
+	if limiter != nil {
+		limiter.ticker.Stop()
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForEachConcurrentlyRateLimit(t *testing.T) {
+	const callsPerSecond = 50
+	const calls = 10
+	interval := time.Second / callsPerSecond
+
+	items := make([]string, calls)
+	for i := range items {
+		items[i] = fmt.Sprintf("%d.example.com", i)
+	}
+
+	limiter := newRateLimiter(callsPerSecond)
+	defer limiter.stop()
+
+	start := time.Now()
+	failures := forEachConcurrently(items, calls, limiter, func(string) error {
+		return nil
+	})
+	elapsed := time.Since(start)
+
+	if len(failures) > 0 {
+		t.Errorf("expected no failures, got %v", failures)
+	}
+	// each call waits for its own tick, even with enough concurrency to run them all at once
+	if minimum := calls * interval; elapsed < minimum {
+		t.Errorf("expected %d calls to take at least %s at %d calls per second, took %s", calls, minimum, callsPerSecond, elapsed)
+	}
+}
+
+func TestForEachConcurrentlyConcurrency(t *testing.T) {
+	const concurrency = 3
+
+	items := make([]string, 12)
+	for i := range items {
+		items[i] = fmt.Sprintf("%d.example.com", i)
+	}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	failures := forEachConcurrently(items, concurrency, nil, func(item string) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		if item == "4.example.com" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	if maxInFlight > concurrency {
+		t.Errorf("expected at most %d calls at once, got %d", concurrency, maxInFlight)
+	}
+	if len(failures) != 1 || failures["4.example.com"] == nil {
+		t.Errorf("expected only 4.example.com to fail, got %v", failures)
+	}
+}
+
+func TestNewRateLimiterTinyRate(t *testing.T) {
+	// the interval of such rates overflows, which made time.NewTicker panic
+	for _, callsPerSecond := range []float64{1e-10, 5e-324} {
+		limiter := newRateLimiter(callsPerSecond)
+		limiter.stop()
+	}
+}
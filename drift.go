@@ -0,0 +1,130 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const missingKey = "missing"
+const unexpectedKey = "unexpected"
+const inSyncKey = "in_sync"
+const completeKey = "complete"
+
+func driftDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainsKey: {
+				Type:        schema.TypeSet,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains which are expected to be verified.",
+			},
+			missingKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The expected domains which are not verified.",
+			},
+			unexpectedKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The verified domains which are not expected. Always empty if the credentials are not allowed to list the verified resources.",
+			},
+			inSyncKey: {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the verified domains are exactly the expected ones. Always false when `complete` is, the unexpected domains being unknown then.",
+			},
+			completeKey: {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether all the verified domains could be compared, i.e. the credentials are allowed to list the verified resources. When they are not, only the expected domains are checked.",
+			},
+		},
+		Description: "Compares the domains verified by the account with a list of expected domains.",
+		Read:        readDrift,
+	}
+}
+
+func readDrift(resourceData *schema.ResourceData, provider interface{}) error {
+	expected := map[string]bool{}
+	for _, domain := range resourceData.Get(domainsKey).(*schema.Set).List() {
+		expected[domain.(string)] = true
+	}
+
+	complete := true
+	verified, listErr := listVerifiedDomains(provider.(configuredProvider))
+	if listErr != nil {
+		if httpStatusCode(listErr) != http.StatusForbidden {
+			return listErr
+		}
+		// without the permission to list, only the expected domains can be checked, one by one
+		log.Printf("[WARN] not allowed to list the verified resources, unexpected domains won't be reported: %s", listErr)
+		complete = false
+		verified = map[string]bool{}
+		for domain := range expected {
+			_, getErr := getWebResource(provider.(configuredProvider), webResourceID(domain))
+			if getErr == nil {
+				verified[domain] = true
+			} else if httpStatusCode(getErr) != http.StatusNotFound {
+				return getErr
+			}
+		}
+	}
+
+	missing := difference(expected, verified)
+	unexpected := difference(verified, expected)
+
+	if setErr := resourceData.Set(missingKey, missing); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(unexpectedKey, unexpected); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(inSyncKey, complete && len(missing) == 0 && len(unexpected) == 0); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(completeKey, complete); setErr != nil {
+		return setErr
+	}
+
+	resourceData.SetId(strconv.Itoa(hashcode.String(strings.Join(difference(expected, nil), ","))))
+
+	return nil
+}
+
+// listVerifiedDomains returns the domains verified by the account.
+// The API does not paginate this list: all the verified resources are returned at once.
+func listVerifiedDomains(provider configuredProvider) (map[string]bool, error) {
+	list, listErr := listWebResources(provider)
+	if listErr != nil {
+		return nil, listErr
+	}
+
+	domains := map[string]bool{}
+	for _, item := range list.Items {
+		if item.Site != nil && item.Site.Type == siteType {
+			domains[item.Site.Identifier] = true
+		}
+	}
+	return domains, nil
+}
+
+// difference returns the sorted elements of a which are not in b.
+func difference(a map[string]bool, b map[string]bool) []string {
+	result := []string{}
+	for element := range a {
+		if !b[element] {
+			result = append(result, element)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestReadDrift(t *testing.T) {
+	testCases := []struct {
+		name               string
+		listForbidden      bool
+		expectedMissing    []string
+		expectedUnexpected []string
+		expectedInSync     bool
+	}{
+		{name: "listed", expectedMissing: []string{"b.example.com"}, expectedUnexpected: []string{"c.example.com"}, expectedInSync: false},
+		{name: "listing forbidden", listForbidden: true, expectedMissing: []string{"b.example.com"}, expectedUnexpected: []string{}, expectedInSync: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/webResource":
+					if testCase.listForbidden {
+						writeAPIError(w, http.StatusForbidden, "Forbidden")
+						return
+					}
+					_, _ = fmt.Fprint(w, `{"items": [{"site": {"identifier": "a.example.com", "type": "INET_DOMAIN"}}, {"site": {"identifier": "c.example.com", "type": "INET_DOMAIN"}}]}`)
+				case "/webResource/dns://a.example.com":
+					_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fa.example.com"}`)
+				default:
+					writeAPIError(w, http.StatusNotFound, "Not Found")
+				}
+			})
+
+			resourceData := schema.TestResourceDataRaw(t, driftDataSource().Schema, map[string]interface{}{
+				domainsKey: []interface{}{"a.example.com", "b.example.com"},
+			})
+			if readErr := readDrift(resourceData, provider); readErr != nil {
+				t.Fatal(readErr)
+			}
+			if missing := ownersList(resourceData.Get(missingKey)); !reflect.DeepEqual(missing, testCase.expectedMissing) {
+				t.Errorf("expected missing %q, got %q", testCase.expectedMissing, missing)
+			}
+			if unexpected := ownersList(resourceData.Get(unexpectedKey)); !reflect.DeepEqual(unexpected, testCase.expectedUnexpected) {
+				t.Errorf("expected unexpected %q, got %q", testCase.expectedUnexpected, unexpected)
+			}
+			if inSync := resourceData.Get(inSyncKey).(bool); inSync != testCase.expectedInSync {
+				t.Errorf("expected in_sync to be %t, got %t", testCase.expectedInSync, inSync)
+			}
+			if complete := resourceData.Get(completeKey).(bool); complete == testCase.listForbidden {
+				t.Errorf("expected complete to be %t, got %t", !testCase.listForbidden, complete)
+			}
+		})
+	}
+}
+
+func TestReadDriftForbiddenListingIsNotInSync(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/webResource" {
+			writeAPIError(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com"}`)
+	})
+
+	resourceData := schema.TestResourceDataRaw(t, driftDataSource().Schema, map[string]interface{}{
+		domainsKey: []interface{}{"example.com"},
+	})
+	if readErr := readDrift(resourceData, provider); readErr != nil {
+		t.Fatal(readErr)
+	}
+	if resourceData.Get(inSyncKey).(bool) {
+		t.Error("expected in_sync to be false while the unexpected domains are unknown")
+	}
+}
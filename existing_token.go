@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func existingTokenDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainKey: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The verified domain, e.g. `example.com`.",
+			},
+			methodKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      defaultVerificationMethod,
+				ValidateFunc: validation.StringInSlice(dnsVerificationMethods, false),
+				Description:  "The DNS verification method to get the token of: `DNS_TXT` or `DNS_CNAME`.",
+			},
+			tokenKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The current token of the domain.",
+			},
+			recordTypeKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The type of the DNS record holding the token: `TXT` or `CNAME`, depending on the method.",
+			},
+			recordNameKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the DNS record holding the token.",
+			},
+			recordValueKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The value of the DNS record holding the token.",
+			},
+			recordValueStringsKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The value of the record split into character-strings of at most 255 bytes, as TXT records require.",
+			},
+		},
+		Description: "https://developers.google.com/site-verification/v1/webResource/get\n\nReturns the current token of a domain the provider's credentials already verified, with the DNS record holding it, e.g. to create the record again after a migration of the DNS zone. Fails if the domain is not verified: use data.googlesiteverification_dns_token to get the token of a domain to verify.",
+		Read:        readExistingToken,
+	}
+}
+
+func readExistingToken(resourceData *schema.ResourceData, provider interface{}) error {
+	domain := bareDomain(resourceData.Get(domainKey).(string))
+	method := resourceData.Get(methodKey).(string)
+
+	if _, getErr := getWebResource(provider.(configuredProvider), webResourceID(domain)); getErr != nil {
+		if httpStatusCode(getErr) == http.StatusNotFound {
+			return fmt.Errorf("%s is not verified by the provider's credentials: use data.googlesiteverification_dns_token to get a token to verify it", domain)
+		}
+		return getErr
+	}
+
+	token, getTokenErr := getToken(provider.(configuredProvider), domain, method)
+	if getTokenErr != nil {
+		return getTokenErr
+	}
+	record, recordErr := dnsRecordFromToken(domain, method, token)
+	if recordErr != nil {
+		return recordErr
+	}
+
+	fields := map[string]interface{}{
+		tokenKey:              token,
+		recordTypeKey:         record.recordType,
+		recordNameKey:         record.name,
+		recordValueKey:        record.value,
+		recordValueStringsKey: txtCharacterStrings(record.value),
+	}
+	for key, value := range fields {
+		if setErr := resourceData.Set(key, value); setErr != nil {
+			return setErr
+		}
+	}
+	resourceData.SetId(domain)
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestReadExistingToken(t *testing.T) {
+	verified := true
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && !verified:
+			writeAPIError(w, http.StatusNotFound, "Not Found")
+		case r.Method == http.MethodGet:
+			_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "owners": ["a@example.com"]}`)
+		default:
+			_, _ = fmt.Fprint(w, `{"method": "DNS_TXT", "token": "google-site-verification=abc"}`)
+		}
+	})
+
+	resourceData := schema.TestResourceDataRaw(t, existingTokenDataSource().Schema, map[string]interface{}{domainKey: "example.com"})
+	if readErr := readExistingToken(resourceData, provider); readErr != nil {
+		t.Fatal(readErr)
+	}
+	if value := resourceData.Get(recordValueKey).(string); value != "google-site-verification=abc" {
+		t.Errorf("expected the token as record value, got %q", value)
+	}
+
+	resourceData = schema.TestResourceDataRaw(t, existingTokenDataSource().Schema, map[string]interface{}{domainKey: "Example.com"})
+	if readErr := readExistingToken(resourceData, provider); readErr != nil {
+		t.Fatal(readErr)
+	}
+	if name := resourceData.Get(recordNameKey).(string); name != "example.com" {
+		t.Errorf("expected the record of the lowercase domain, got %q", name)
+	}
+
+	verified = false
+	resourceData = schema.TestResourceDataRaw(t, existingTokenDataSource().Schema, map[string]interface{}{domainKey: "example.com"})
+	if readErr := readExistingToken(resourceData, provider); readErr == nil || !strings.Contains(readErr.Error(), "googlesiteverification_dns_token") {
+		t.Errorf("expected an unverified domain to be refused with a pointer to the token data source, got %v", readErr)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iamcredentials/v1"
+)
+
+const externalAccountType = "external_account"
+
+// defaultSTSTokenURL is where the subject tokens are exchanged when the configuration doesn't tell.
+const defaultSTSTokenURL = "https://sts.googleapis.com/v1/token"
+
+// impersonationURLRegexp extracts the email of the service account from a service_account_impersonation_url.
+var impersonationURLRegexp = regexp.MustCompile(`/serviceAccounts/([^/:]+):generateAccessToken$`)
+
+// externalAccount is the part of a Workload Identity Federation configuration the provider uses.
+type externalAccount struct {
+	Type                           string           `json:"type"`
+	Audience                       string           `json:"audience"`
+	SubjectTokenType               string           `json:"subject_token_type"`
+	TokenURL                       string           `json:"token_url"`
+	ServiceAccountImpersonationURL string           `json:"service_account_impersonation_url"`
+	CredentialSource               credentialSource `json:"credential_source"`
+}
+
+// credentialSource tells where to read the subject token of an external account from.
+type credentialSource struct {
+	File    string            `json:"file"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Format  struct {
+		Type                  string `json:"type"`
+		SubjectTokenFieldName string `json:"subject_token_field_name"`
+	} `json:"format"`
+}
+
+// isExternalAccount reports whether the credentials are a Workload Identity Federation configuration
+// rather than a key, e.g. the one written by the CI systems authenticating without keys.
+func isExternalAccount(credentialsJSON []byte) bool {
+	var credentialsType struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(credentialsJSON, &credentialsType) == nil && credentialsType.Type == externalAccountType
+}
+
+// externalAccountCredentials returns credentials exchanging the subject token of a Workload Identity Federation
+// configuration for access tokens with the Security Token Service, then impersonating its service account if any.
+// The version of golang.org/x/oauth2 the provider uses predates the support of these configurations.
+func externalAccountCredentials(ctx context.Context, credentialsJSON []byte, scopes []string) (*google.Credentials, error) {
+	var account externalAccount
+	if parseErr := json.Unmarshal(credentialsJSON, &account); parseErr != nil {
+		return nil, fmt.Errorf("parsing the external account credentials: %w", parseErr)
+	}
+	if account.TokenURL == "" {
+		account.TokenURL = defaultSTSTokenURL
+	}
+	switch {
+	case account.CredentialSource.File != "":
+		if _, statErr := os.Stat(account.CredentialSource.File); statErr != nil {
+			return nil, fmt.Errorf("the external account credentials read their subject token from %s, which can't be read: is the OIDC token of the CI job written there? %w", account.CredentialSource.File, statErr)
+		}
+	case account.CredentialSource.URL != "":
+	default:
+		return nil, errors.New("the external account credentials must read their subject token from a file or a URL, the other credential sources are not supported")
+	}
+
+	exchangeScopes := scopes
+	target := ""
+	if account.ServiceAccountImpersonationURL != "" {
+		match := impersonationURLRegexp.FindStringSubmatch(account.ServiceAccountImpersonationURL)
+		if match == nil {
+			return nil, fmt.Errorf("unexpected service_account_impersonation_url %q in the external account credentials", account.ServiceAccountImpersonationURL)
+		}
+		target = match[1]
+		// the exchanged token only needs to be allowed to impersonate, the service account gets the scopes
+		exchangeScopes = []string{iamcredentials.CloudPlatformScope}
+	}
+
+	credentials := &google.Credentials{
+		TokenSource: oauth2.ReuseTokenSource(nil, stsTokenSource{ctx: ctx, account: account, scopes: exchangeScopes}),
+	}
+	if target == "" {
+		return credentials, nil
+	}
+	return impersonatedCredentials(ctx, credentials, target, nil, scopes)
+}
+
+// stsTokenSource exchanges the subject token of an external account for access tokens.
+type stsTokenSource struct {
+	ctx     context.Context
+	account externalAccount
+	scopes  []string
+}
+
+func (source stsTokenSource) Token() (*oauth2.Token, error) {
+	subjectToken, subjectErr := source.account.CredentialSource.subjectToken(source.ctx)
+	if subjectErr != nil {
+		return nil, fmt.Errorf("reading the subject token of the external account credentials: %w", subjectErr)
+	}
+
+	form := url.Values{
+		"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"audience":             {source.account.Audience},
+		"scope":                {strings.Join(source.scopes, " ")},
+		"requested_token_type": {"urn:ietf:params:oauth:token-type:access_token"},
+		"subject_token_type":   {source.account.SubjectTokenType},
+		"subject_token":        {subjectToken},
+	}
+	request, requestErr := http.NewRequestWithContext(source.ctx, http.MethodPost, source.account.TokenURL, strings.NewReader(form.Encode()))
+	if requestErr != nil {
+		return nil, requestErr
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, exchangeErr := doTokenRequest(request)
+	if exchangeErr != nil {
+		return nil, fmt.Errorf("exchanging the subject token of the external account credentials: %w", exchangeErr)
+	}
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if parseErr := json.Unmarshal(body, &response); parseErr != nil {
+		return nil, fmt.Errorf("exchanging the subject token of the external account credentials: %w", parseErr)
+	}
+	return &oauth2.Token{
+		AccessToken: response.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// subjectToken reads the token to exchange, from a file or a URL, as text or in a JSON field.
+func (source credentialSource) subjectToken(ctx context.Context) (string, error) {
+	var content []byte
+	if source.File != "" {
+		var readErr error
+		content, readErr = os.ReadFile(source.File)
+		if readErr != nil {
+			return "", readErr
+		}
+	} else {
+		request, requestErr := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+		if requestErr != nil {
+			return "", requestErr
+		}
+		for name, value := range source.Headers {
+			request.Header.Set(name, value)
+		}
+		var getErr error
+		content, getErr = doTokenRequest(request)
+		if getErr != nil {
+			return "", getErr
+		}
+	}
+
+	switch source.Format.Type {
+	case "", "text":
+		return strings.TrimSpace(string(content)), nil
+	case "json":
+		var fields map[string]interface{}
+		if parseErr := json.Unmarshal(content, &fields); parseErr != nil {
+			return "", parseErr
+		}
+		token, ok := fields[source.Format.SubjectTokenFieldName].(string)
+		if !ok {
+			return "", fmt.Errorf("no %q field holds the subject token", source.Format.SubjectTokenFieldName)
+		}
+		return token, nil
+	default:
+		return "", fmt.Errorf("unsupported subject token format %q", source.Format.Type)
+	}
+}
+
+// doTokenRequest sends a request to a token endpoint and returns the body of its successful response.
+// It goes through the HTTP client of the request's context, if any, like the other token requests of the oauth2 package.
+func doTokenRequest(request *http.Request) ([]byte, error) {
+	client := http.DefaultClient
+	if contextClient, ok := request.Context().Value(oauth2.HTTPClient).(*http.Client); ok {
+		client = contextClient
+	}
+	response, doErr := client.Do(request)
+	if doErr != nil {
+		return nil, doErr
+	}
+	defer response.Body.Close()
+
+	body, readErr := io.ReadAll(response.Body)
+	if readErr != nil {
+		return nil, readErr
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s: %s", request.URL.Host, response.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestExternalAccountCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if parseErr := r.ParseForm(); parseErr != nil {
+			t.Fatal(parseErr)
+		}
+		if subjectToken := r.PostForm.Get("subject_token"); subjectToken != "oidc-token" {
+			t.Errorf("expected the subject token to be exchanged, got %q", subjectToken)
+		}
+		if audience := r.PostForm.Get("audience"); audience != "//iam.googleapis.com/pool" {
+			t.Errorf("unexpected audience %q", audience)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"access_token": "access-token", "expires_in": 3600}`)
+	}))
+	defer server.Close()
+
+	directory := t.TempDir()
+	textFile := filepath.Join(directory, "token")
+	jsonFile := filepath.Join(directory, "token.json")
+	if writeErr := os.WriteFile(textFile, []byte("oidc-token\n"), 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	if writeErr := os.WriteFile(jsonFile, []byte(`{"value": "oidc-token"}`), 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	testCases := []struct {
+		name             string
+		credentialSource string
+		expectedErr      string
+	}{
+		{name: "text file", credentialSource: fmt.Sprintf(`{"file": %q}`, textFile)},
+		{name: "json file", credentialSource: fmt.Sprintf(`{"file": %q, "format": {"type": "json", "subject_token_field_name": "value"}}`, jsonFile)},
+		{name: "missing file", credentialSource: fmt.Sprintf(`{"file": %q}`, filepath.Join(directory, "missing")), expectedErr: "which can't be read"},
+		{name: "no source", credentialSource: `{"environment_id": "aws1"}`, expectedErr: "from a file or a URL"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			credentialsJSON := []byte(fmt.Sprintf(`{
+				"type": "external_account",
+				"audience": "//iam.googleapis.com/pool",
+				"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+				"token_url": %q,
+				"credential_source": %s
+			}`, server.URL, testCase.credentialSource))
+			if !isExternalAccount(credentialsJSON) {
+				t.Fatal("expected the credentials to be recognized as an external account")
+			}
+
+			credentials, credentialsErr := externalAccountCredentials(context.Background(), credentialsJSON, []string{"scope"})
+			if testCase.expectedErr != "" {
+				if credentialsErr == nil || !strings.Contains(credentialsErr.Error(), testCase.expectedErr) {
+					t.Fatalf("expected an error containing %q, got %v", testCase.expectedErr, credentialsErr)
+				}
+				return
+			}
+			if credentialsErr != nil {
+				t.Fatal(credentialsErr)
+			}
+			token, tokenErr := credentials.TokenSource.Token()
+			if tokenErr != nil {
+				t.Fatal(tokenErr)
+			}
+			if token.AccessToken != "access-token" {
+				t.Errorf("expected the exchanged access token, got %q", token.AccessToken)
+			}
+		})
+	}
+}
+
+func TestDoTokenRequestUsesContextClient(t *testing.T) {
+	var used bool
+	client := &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		used = true
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("token"))}, nil
+	})}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://sts.googleapis.com/v1/token", nil)
+	body, requestErr := doTokenRequest(request)
+	if requestErr != nil {
+		t.Fatal(requestErr)
+	}
+	if !used || string(body) != "token" {
+		t.Errorf("expected the token request to go through the client of the context, got %q", body)
+	}
+}
@@ -0,0 +1,12 @@
+package main
+
+import "time"
+
+// iamPropagationTimeout bounds how long 403 errors are retried when waiting for IAM permissions to propagate.
+const iamPropagationTimeout = 2 * time.Minute
+
+// forbiddenIsRetryable reports whether a 403 is worth retrying because permissions granted
+// right before start may still be propagating. It never is unless wait_for_iam is enabled.
+func (provider configuredProvider) forbiddenIsRetryable(start time.Time) bool {
+	return provider.waitForIAM && time.Since(start) < iamPropagationTimeout
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForbiddenIsRetryable(t *testing.T) {
+	testCases := []struct {
+		name       string
+		waitForIAM bool
+		elapsed    time.Duration
+		expected   bool
+	}{
+		{name: "disabled", waitForIAM: false, elapsed: 0, expected: false},
+		{name: "just started", waitForIAM: true, elapsed: 0, expected: true},
+		{name: "within the window", waitForIAM: true, elapsed: iamPropagationTimeout - time.Second, expected: true},
+		{name: "past the window", waitForIAM: true, elapsed: iamPropagationTimeout + time.Second, expected: false},
+		{name: "long past the window", waitForIAM: true, elapsed: time.Hour, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider := configuredProvider{waitForIAM: testCase.waitForIAM}
+			if actual := provider.forbiddenIsRetryable(time.Now().Add(-testCase.elapsed)); actual != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+const impersonateServiceAccountKey = "impersonate_service_account"
+const impersonateServiceAccountDelegatesKey = "impersonate_service_account_delegates"
+
+// The environment variables the impersonation is configured from when the provider block does not set it, like the google provider does.
+const impersonateServiceAccountEnv = "GOOGLE_IMPERSONATE_SERVICE_ACCOUNT"
+const impersonateServiceAccountDelegatesEnv = "GOOGLE_IMPERSONATE_SERVICE_ACCOUNT_DELEGATES"
+
+// impersonatedTokenLifetime is how long the tokens of the impersonated service account last.
+const impersonatedTokenLifetime = time.Hour
+
+// impersonatedTokenSource mints access tokens for a service account with the IAM Credentials API.
+type impersonatedTokenSource struct {
+	service   *iamcredentials.Service
+	target    string
+	delegates []string
+	scopes    []string
+}
+
+func (source impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	response, generateErr := source.service.Projects.ServiceAccounts.GenerateAccessToken(serviceAccountName(source.target), &iamcredentials.GenerateAccessTokenRequest{
+		Delegates: source.delegates,
+		Scope:     source.scopes,
+		Lifetime:  fmt.Sprintf("%ds", int(impersonatedTokenLifetime.Seconds())),
+	}).Do()
+	if generateErr != nil {
+		return nil, fmt.Errorf("impersonating %s: %w", source.target, generateErr)
+	}
+
+	expiry, parseErr := time.Parse(time.RFC3339, response.ExpireTime)
+	if parseErr != nil {
+		return nil, fmt.Errorf("impersonating %s: unexpected expiry time %q: %w", source.target, response.ExpireTime, parseErr)
+	}
+	return &oauth2.Token{AccessToken: response.AccessToken, TokenType: "Bearer", Expiry: expiry}, nil
+}
+
+// impersonatedCredentials returns credentials making the API calls as the target service account,
+// going through the delegates if any, with tokens obtained with the base credentials.
+// The package google.golang.org/api/impersonate does the same, but with a more recent version of the API client.
+func impersonatedCredentials(ctx context.Context, base *google.Credentials, target string, delegates []string, scopes []string) (*google.Credentials, error) {
+	serviceOptions := []option.ClientOption{option.WithCredentials(base)}
+	if contextClient, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		// the IAM Credentials API is called through the provider's transport, like its token endpoint
+		httpClient, httpClientErr := newHTTPClient(ctx, contextClient.Transport, serviceOptions...)
+		if httpClientErr != nil {
+			return nil, httpClientErr
+		}
+		serviceOptions = append(serviceOptions, option.WithHTTPClient(httpClient))
+	}
+	service, serviceErr := iamcredentials.NewService(ctx, serviceOptions...)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	delegateNames := make([]string, len(delegates))
+	for i, delegate := range delegates {
+		delegateNames[i] = serviceAccountName(delegate)
+	}
+
+	return &google.Credentials{
+		ProjectID: base.ProjectID,
+		TokenSource: oauth2.ReuseTokenSource(nil, impersonatedTokenSource{
+			service:   service,
+			target:    target,
+			delegates: delegateNames,
+			scopes:    scopes,
+		}),
+	}, nil
+}
+
+// serviceAccountName returns the resource name of a service account, from its email.
+func serviceAccountName(email string) string {
+	return fmt.Sprintf("projects/-/serviceAccounts/%s", email)
+}
+
+// impersonationDelegates returns the configured delegates, or the comma-separated ones of the environment if none are.
+// Lists can't have a DefaultFunc, hence the environment being read here.
+func impersonationDelegates(resourceData *schema.ResourceData) []string {
+	var delegates []string
+	for _, delegate := range resourceData.Get(impersonateServiceAccountDelegatesKey).([]interface{}) {
+		delegates = append(delegates, delegate.(string))
+	}
+	if len(delegates) > 0 {
+		return delegates
+	}
+	for _, delegate := range strings.Split(os.Getenv(impersonateServiceAccountDelegatesEnv), ",") {
+		if delegate = strings.TrimSpace(delegate); delegate != "" {
+			delegates = append(delegates, delegate)
+		}
+	}
+	return delegates
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+)
+
+func TestImpersonatedTokenSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/-/serviceAccounts/target@project.iam.gserviceaccount.com:generateAccessToken" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var request iamcredentials.GenerateAccessTokenRequest
+		if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+			t.Fatal(decodeErr)
+		}
+		if expected := []string{"projects/-/serviceAccounts/delegate@project.iam.gserviceaccount.com"}; !reflect.DeepEqual(request.Delegates, expected) {
+			t.Errorf("expected the delegates %q, got %q", expected, request.Delegates)
+		}
+		if !reflect.DeepEqual(request.Scope, oauthScopes) {
+			t.Errorf("expected the scopes %q, got %q", oauthScopes, request.Scope)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"accessToken": "impersonated", "expireTime": "2030-01-02T15:04:05Z"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	service, serviceErr := iamcredentials.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	if serviceErr != nil {
+		t.Fatal(serviceErr)
+	}
+
+	token, tokenErr := impersonatedTokenSource{
+		service:   service,
+		target:    "target@project.iam.gserviceaccount.com",
+		delegates: []string{serviceAccountName("delegate@project.iam.gserviceaccount.com")},
+		scopes:    oauthScopes,
+	}.Token()
+	if tokenErr != nil {
+		t.Fatal(tokenErr)
+	}
+	if token.AccessToken != "impersonated" || token.Expiry.Year() != 2030 {
+		t.Errorf("unexpected token %#v", token)
+	}
+}
+
+func TestImpersonationFromEnvironment(t *testing.T) {
+	t.Setenv(impersonateServiceAccountEnv, "env@project.iam.gserviceaccount.com")
+	t.Setenv(impersonateServiceAccountDelegatesEnv, "a@project.iam.gserviceaccount.com, b@project.iam.gserviceaccount.com")
+	providerSchema := Provider().(*schema.Provider).Schema
+
+	testCases := []struct {
+		name              string
+		config            map[string]interface{}
+		expectedTarget    string
+		expectedDelegates []string
+	}{
+		{
+			name:              "environment",
+			config:            map[string]interface{}{},
+			expectedTarget:    "env@project.iam.gserviceaccount.com",
+			expectedDelegates: []string{"a@project.iam.gserviceaccount.com", "b@project.iam.gserviceaccount.com"},
+		},
+		{
+			name: "configuration first",
+			config: map[string]interface{}{
+				impersonateServiceAccountKey:          "config@project.iam.gserviceaccount.com",
+				impersonateServiceAccountDelegatesKey: []interface{}{"c@project.iam.gserviceaccount.com"},
+			},
+			expectedTarget:    "config@project.iam.gserviceaccount.com",
+			expectedDelegates: []string{"c@project.iam.gserviceaccount.com"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resourceData := schema.TestResourceDataRaw(t, providerSchema, testCase.config)
+			if target := resourceData.Get(impersonateServiceAccountKey).(string); target != testCase.expectedTarget {
+				t.Errorf("expected to impersonate %s, got %s", testCase.expectedTarget, target)
+			}
+			if delegates := impersonationDelegates(resourceData); !reflect.DeepEqual(delegates, testCase.expectedDelegates) {
+				t.Errorf("expected the delegates %q, got %q", testCase.expectedDelegates, delegates)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const pathKey = "path"
+const extraKey = "extra"
+const matchingKey = "matching"
+
+var bareDomainRegexp = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z][a-z0-9-]*[a-z0-9]$`)
+
+func inventoryDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			pathKey: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The path of the inventory file, listing one expected domain per line. Empty lines and lines starting with `#` are ignored.",
+			},
+			missingKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains of the inventory which are not verified.",
+			},
+			extraKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The verified domains which are not in the inventory.",
+			},
+			matchingKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains of the inventory which are verified.",
+			},
+			inSyncKey: {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the verified domains are exactly the ones of the inventory.",
+			},
+		},
+		Description: "Reconciles the domains verified by the account with an inventory file, e.g. maintained in the same repository as the configuration.",
+		Read:        readInventory,
+	}
+}
+
+func readInventory(resourceData *schema.ResourceData, provider interface{}) error {
+	path := resourceData.Get(pathKey).(string)
+
+	expected, inventoryErr := readInventoryFile(path)
+	if inventoryErr != nil {
+		return inventoryErr
+	}
+
+	verified, listErr := listVerifiedDomains(provider.(configuredProvider))
+	if listErr != nil {
+		return listErr
+	}
+
+	missing := difference(expected, verified)
+	extra := difference(verified, expected)
+	matching := difference(expected, setOf(missing))
+
+	if setErr := resourceData.Set(missingKey, missing); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(extraKey, extra); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(matchingKey, matching); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(inSyncKey, len(missing) == 0 && len(extra) == 0); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId(strconv.Itoa(hashcode.String(path)))
+
+	return nil
+}
+
+// readInventoryFile reads the domains of an inventory file, reporting all its malformed lines at once.
+func readInventoryFile(path string) (map[string]bool, error) {
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer file.Close()
+
+	domains := map[string]bool{}
+	var malformedErr *multierror.Error
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		domain := strings.ToLower(line)
+		if !bareDomainRegexp.MatchString(domain) {
+			malformedErr = multierror.Append(malformedErr, fmt.Errorf("%s:%d: %q is not a domain like example.com", path, lineNumber, line))
+			continue
+		}
+		domains[domain] = true
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, scanErr
+	}
+
+	return domains, malformedErr.ErrorOrNil()
+}
+
+func setOf(elements []string) map[string]bool {
+	set := make(map[string]bool, len(elements))
+	for _, element := range elements {
+		set[element] = true
+	}
+	return set
+}
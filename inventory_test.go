@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// writeInventoryFile writes the inventory content to a temporary file, returning its path.
+func writeInventoryFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "domains.txt")
+	if writeErr := os.WriteFile(path, []byte(content), 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	return path
+}
+
+func TestReadInventoryFile(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       string
+		expected      map[string]bool
+		expectedLines []string
+	}{
+		{
+			name:     "domains",
+			content:  "# production\na.example.com\n\n  B.Example.com  \n",
+			expected: map[string]bool{"a.example.com": true, "b.example.com": true},
+		},
+		{
+			name:     "empty",
+			content:  "# nothing yet\n",
+			expected: map[string]bool{},
+		},
+		{
+			name:          "malformed lines",
+			content:       "a.example.com\nhttps://b.example.com/\nexample\nc.example.com\n",
+			expectedLines: []string{":2: \"https://b.example.com/\"", ":3: \"example\""},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			domains, inventoryErr := readInventoryFile(writeInventoryFile(t, testCase.content))
+			if testCase.expectedLines != nil {
+				if inventoryErr == nil {
+					t.Fatal("expected the malformed inventory to be reported")
+				}
+				for _, line := range testCase.expectedLines {
+					if !strings.Contains(inventoryErr.Error(), line) {
+						t.Errorf("expected the error to report %s, got %s", line, inventoryErr)
+					}
+				}
+				return
+			}
+			if inventoryErr != nil {
+				t.Fatal(inventoryErr)
+			}
+			if !reflect.DeepEqual(domains, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, domains)
+			}
+		})
+	}
+
+	if _, inventoryErr := readInventoryFile(filepath.Join(t.TempDir(), "missing.txt")); inventoryErr == nil {
+		t.Error("expected a missing inventory file to be reported")
+	}
+}
+
+func TestReadInventory(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/webResource" {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"items": [{"site": {"identifier": "a.example.com", "type": "INET_DOMAIN"}}, {"site": {"identifier": "c.example.com", "type": "INET_DOMAIN"}}, {"site": {"identifier": "https://b.example.com/", "type": "SITE"}}]}`)
+	})
+
+	resourceData := schema.TestResourceDataRaw(t, inventoryDataSource().Schema, map[string]interface{}{
+		pathKey: writeInventoryFile(t, "a.example.com\nb.example.com\n"),
+	})
+	if readErr := readInventory(resourceData, provider); readErr != nil {
+		t.Fatal(readErr)
+	}
+	if missing := ownersList(resourceData.Get(missingKey)); !reflect.DeepEqual(missing, []string{"b.example.com"}) {
+		t.Errorf("expected missing [b.example.com], got %q", missing)
+	}
+	if extra := ownersList(resourceData.Get(extraKey)); !reflect.DeepEqual(extra, []string{"c.example.com"}) {
+		t.Errorf("expected extra [c.example.com], got %q", extra)
+	}
+	if matching := ownersList(resourceData.Get(matchingKey)); !reflect.DeepEqual(matching, []string{"a.example.com"}) {
+		t.Errorf("expected matching [a.example.com], got %q", matching)
+	}
+	if inSync := resourceData.Get(inSyncKey).(bool); inSync {
+		t.Error("expected in_sync to be false")
+	}
+}
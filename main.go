@@ -3,24 +3,40 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/plugin"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/logging/v2"
 	"google.golang.org/api/option"
 	"google.golang.org/api/siteverification/v1"
+	"google.golang.org/api/webmasters/v3"
 )
 
 //go:generate go run github.com/hashicorp/terraform-plugin-docs/cmd/tfplugindocs
 
+// version is set by the release build.
+var version = "dev"
+
 func main() {
 	if len(os.Args) > 1 && os.Args[1] == "install" {
 		install()
@@ -31,18 +47,76 @@ func main() {
 	})
 }
 
+const idKey = "id"
 const tokenKey = "token"
 const domainKey = "domain"
 const recordTypeKey = "record_type"
 const recordNameKey = "record_name"
 const recordValueKey = "record_value"
+const recordNameOverrideKey = "record_name_override"
 const credentialsKey = "credentials"
+const accessTokenKey = "access_token"
+const scopesKey = "scopes"
+const billingProjectKey = "billing_project"
+const endpointKey = "endpoint"
+const lastStatusCodeKey = "last_status_code"
+const displayIDKey = "display_id"
+const webResourceIDKey = "web_resource_id"
+const tokenStaleKey = "token_stale"
+const checkTokenStaleKey = "check_token_stale"
+const verifiedTokenKey = "verified_token"
+const verifyExistingKey = "verify_existing"
+const alreadyVerifiedKey = "already_verified"
+const newlyVerifiedKey = "newly_verified"
+const verificationTriggersKey = "verification_triggers"
+const retryBaseDelayKey = "retry_base_delay"
+const retryMultiplierKey = "retry_multiplier"
+const retryMaxDelayKey = "retry_max_delay"
+const retryPauseFileKey = "retry_pause_file"
+const deleteRetryOnKey = "delete_retry_on"
+const retryJitterKey = "retry_jitter"
+const disableRetriesKey = "disable_retries"
+const readOnlyKey = "read_only"
+const maxRetriesKey = "max_retries"
+const tokenMethodKey = "token_method"
+const tokenTypeKey = "token_type"
+const validateCredentialsKey = "validate_credentials"
+const waitForIAMKey = "wait_for_iam"
+const skipReadAfterCreateKey = "skip_read_after_create"
+const allowedMethodsKey = "allowed_methods"
 const siteType = "INET_DOMAIN"
-const verificationMethod = "DNS_TXT"
-const tokenStillExists = "You cannot unverify your ownership of this site until your verification token (meta tag, HTML file, Google Analytics tracking code, Google Tag Manager container code, or DNS record) has been removed."
+const urlSiteType = "SITE"
+const androidAppSiteType = "ANDROID_APP"
+const siteTypeKey = "site_type"
+
+// recordNameRegexp matches the DNS names, including the labels starting with an underscore such as _verification.example.com.
+var recordNameRegexp = regexp.MustCompile(`^([A-Za-z0-9_]([A-Za-z0-9_-]*[A-Za-z0-9])?\.)+[A-Za-z][A-Za-z0-9-]*[A-Za-z0-9]\.?$`)
+
+// defaultVerificationMethod is the verification method used when none is configured.
+const defaultVerificationMethod = "DNS_TXT"
+
+// verificationMethods are all the verification methods supported by the API.
+var verificationMethods = []string{"DNS_TXT", "DNS_CNAME", "META", "FILE", "ANALYTICS", "TAG_MANAGER"}
+
+// oauthScopes are the scopes requested for the credentials.
+var oauthScopes = []string{siteverification.SiteverificationScope, webmasters.WebmastersReadonlyScope}
+
+// cloudDNSScopes are the scopes requested for the credentials of the Cloud DNS client, which only
+// googlesiteverification_dns_managed uses: they are only found when it first needs them.
+var cloudDNSScopes = []string{dns.NdevClouddnsReadwriteScope}
+
+// tokenNotFound is part of the error Google returns when it can't find the token yet, e.g. because the DNS record is still propagating.
+const tokenNotFound = "verification token could not be found"
+
+// alreadyOwnedReasons are the reasons of the errors Google returns when the web resource is verified by another account,
+// which it does not document: the conflicts are matched as well, and so is the message.
+var alreadyOwnedReasons = []string{"alreadyExists", "conflict", "duplicate"}
+
+// ownedByAnother is part of the message of the errors Google returns when the web resource is verified by another account.
+const ownedByAnother = "owned by another"
 
 func Provider() terraform.ResourceProvider {
-	return &schema.Provider{
+	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			credentialsKey: {
 				Type:     schema.TypeString,
@@ -52,172 +126,985 @@ func Provider() terraform.ResourceProvider {
 					"GOOGLE_CLOUD_KEYFILE_JSON",
 					"GCLOUD_KEYFILE_JSON",
 				}, ""),
-				Description: "Either the path to or the contents of a [service account key file](https://cloud.google.com/iam/docs/creating-managing-service-account-keys) in JSON format. If not provided, the [application default credentials](https://cloud.google.com/sdk/gcloud/reference/auth/application-default) will be used.",
+				Description: "Either the path to or the contents of a [service account key file](https://cloud.google.com/iam/docs/creating-managing-service-account-keys) in JSON format. The contents can also be base64-encoded, for the CI systems which only pass secrets on a single line, or kept in Secret Manager and referenced as `sm://projects/<project>/secrets/<secret>/versions/<version>`: the secret is then read with the application default credentials, which need the `roles/secretmanager.secretAccessor` role on it, and the key it holds authenticates everything else. [Workload Identity Federation](https://cloud.google.com/iam/docs/workload-identity-federation) configurations (`external_account` credentials) are supported too, with their subject token read from a file or a URL, here or through `GOOGLE_APPLICATION_CREDENTIALS`. If not provided, the [application default credentials](https://cloud.google.com/sdk/gcloud/reference/auth/application-default) will be used.",
+			},
+			accessTokenKey: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("GOOGLE_OAUTH_ACCESS_TOKEN", ""),
+				Description: "An OAuth2 access token to make the API calls with, e.g. from `gcloud auth print-access-token`, instead of `credentials`. It is used as is and never refreshed, so it must outlive the run. Conflicts with `credentials`.",
+			},
+			scopesKey: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The OAuth scopes to request for the credentials, instead of `https://www.googleapis.com/auth/siteverification` and `https://www.googleapis.com/auth/webmasters.readonly` (plus `https://www.googleapis.com/auth/logging.write` with `audit_log_name`). The Cloud DNS calls of googlesiteverification_dns_managed always use separate credentials with the `https://www.googleapis.com/auth/ndev.clouddns.readwrite` scope alone, found when it first needs them. For example `https://www.googleapis.com/auth/siteverification.verify_only`, which is enough to get tokens and verify, but not to unverify nor to read the owners. Not used with `access_token`, whose scopes are the ones it was minted with.",
+			},
+			billingProjectKey: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("GOOGLE_CLOUD_QUOTA_PROJECT", ""),
+				Description: "The project to bill the API calls to and count them against the quota of, like the `billing_project` of the Google provider. Needed with user credentials when the API asks for a quota project. Defaults to the `GOOGLE_CLOUD_QUOTA_PROJECT` environment variable, which is otherwise not read by the API client this provider uses; the attribute takes precedence over it. The credentials need the `serviceusage.services.use` permission on the project.",
+			},
+			endpointKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("GOOGLE_SITE_VERIFICATION_CUSTOM_ENDPOINT", ""),
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+				Description:  "The base URL of the Site Verification API, instead of `https://www.googleapis.com/siteVerification/v1/`, e.g. to go through a Private Service Connect endpoint or to test against a fake server. Defaults to the `GOOGLE_SITE_VERIFICATION_CUSTOM_ENDPOINT` environment variable, like the custom endpoints of the Google provider. The other APIs the provider calls keep their default endpoint.",
+			},
+			impersonateServiceAccountKey: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc(impersonateServiceAccountEnv, ""),
+				Description: "The email of a service account to impersonate: all the API calls are made as this service account, with short-lived tokens obtained with the base credentials, which need the `roles/iam.serviceAccountTokenCreator` role on it. Can also be set with the `GOOGLE_IMPERSONATE_SERVICE_ACCOUNT` environment variable.",
+			},
+			impersonateServiceAccountDelegatesKey: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The emails of the service accounts in the delegation chain from the base credentials to `impersonate_service_account`, each one being allowed to impersonate the next. Can also be set with the `GOOGLE_IMPERSONATE_SERVICE_ACCOUNT_DELEGATES` environment variable, as a comma-separated list.",
+			},
+			retryBaseDelayKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "500ms",
+				ValidateFunc: validateDuration,
+				Description:  "How long to wait before retrying a failed verification or unverification the first time, e.g. `2s`.",
+			},
+			retryMultiplierKey: {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Default:      2.0,
+				ValidateFunc: validation.FloatAtLeast(1),
+				Description:  "By how much the wait between two retries grows after each attempt, up to `retry_max_delay`.",
+			},
+			retryMaxDelayKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "10s",
+				ValidateFunc: validateDuration,
+				Description:  "The longest wait between two retries, e.g. `1m` to spare the API quota while waiting for a DNS record to propagate. The create and delete timeouts still apply.",
+			},
+			retryJitterKey: {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Default:      0.1,
+				ValidateFunc: validation.FloatBetween(0, 1),
+				Description:  "The fraction by which each wait between two retries is randomly shortened or lengthened, so that many resources retrying at the same time spread their API calls.",
+			},
+			disableRetriesKey: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("GOOGLE_SITE_VERIFICATION_DISABLE_RETRIES", false),
+				Description: "Whether to try everything only once, returning the first error as is, instead of retrying until the timeouts: the verifications fail right away while the token is not visible yet, and so do the unverifications while it still is, as well as the `dns_check` waits. For the fast feedback of local development and test runs, not for production. Can also be set with the `GOOGLE_SITE_VERIFICATION_DISABLE_RETRIES` environment variable.",
+			},
+			maxRetriesKey: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The most times a verification or an unverification is retried before returning its last error, however much of the timeout is left, e.g. to spare the API quota when the failure is quick to return. 0, the default, for no cap but the timeouts. See `disable_retries` to try only once.",
+			},
+			readOnlyKey: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("GOOGLE_SITE_VERIFICATION_READ_ONLY", false),
+				Description: "Whether to refuse every change to the verifications, their owners and the Cloud DNS records, failing the creates, updates and deletes instead, while the reads and the data sources keep working. A guard rail for the audit and reporting workspaces, so that a misapplied plan can't unverify a domain. The owner policy is then reported but never remediated. Can also be set with the `GOOGLE_SITE_VERIFICATION_READ_ONLY` environment variable.",
+			},
+			retryPauseFileKey: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The path of a file which pauses all the retries for as long as it exists, e.g. to throttle the API calls of a long apply during an incident without interrupting it: `touch` it to pause, remove it to resume. The create and delete timeouts still apply while paused, and interrupting Terraform ends the pause.",
+			},
+			deleteRetryOnKey: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.NoZeroValues},
+				Description: "Extra substrings of the error messages to retry the unverifications on, as an escape hatch for the errors the provider wrongly takes for permanent. The unverifications are already retried while Google still sees the token, which it tells with a `400 Bad Request` whatever the language of the message, and on rate limiting and server errors.",
+			},
+			validateCredentialsKey: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to check the credentials when configuring the provider, to report common problems (such as a skewed system clock, or a missing `https://www.googleapis.com/auth/siteverification` scope) with a clearer error than the API calls would. The API access is checked by listing the verified resources, which is skipped when the configured `scopes` don't allow it.",
+			},
+			waitForIAMKey: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to retry permission denied (403) errors for up to 2 minutes when getting tokens and verifying, to wait for a freshly granted IAM role to propagate. These errors are never retried for longer, nor when this is disabled.",
+			},
+			skipReadAfterCreateKey: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to skip reading the `googlesiteverification_dns` resources back once verified, which saves several API calls per domain when verifying many of them. The attributes which are only read, such as the owners when they are not configured, `token_stale` or `search_console_ready`, are then unknown until the next refresh.",
+			},
+			allowedMethodsKey: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringInSlice(verificationMethods, false)},
+				Description: "The only verification methods resources and data sources are allowed to use, to enforce a policy. All of them are allowed if not provided.",
+			},
+			metricsFileKey: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The path of a file to write metrics about the verifications and unverifications to, in the Prometheus text format (e.g. for the node exporter's textfile collector). For each operation and domain, the file holds the duration, the number of attempts and the success of the last operation, as well as when it happened. Operations older than a week are removed from the file. Disabled if not provided.",
+			},
+			ownerChangeWebhookKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+				Description:  "A URL to POST to whenever the owners of a `googlesiteverification_dns` resource change, including when it is created. The JSON payload has the `domain`, the `id` of the resource, its `owners` and its `previous_owners`. Failing to call it is logged, but never fails the apply. Disabled if not provided.",
+			},
+			preflightCheckKey: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to check, once before the first verification, that the credentials can use the Site Verification API, and fail with what they miss otherwise (see the `googlesiteverification_auth_check` data source). This turns the 403 errors the verifications would get into an early and specific diagnostic. Skipped when `scopes` leave out the full Site Verification scope, e.g. with `verify_only`, which is refused the listing the check relies on.",
+			},
+			auditLogNameKey: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "A Cloud Logging log to write an audit entry to, with the same credentials, whenever a domain is verified or unverified. The JSON payload has the `action` (`create` or `delete`), the `domain`, the `method`, the `principal` (the service account of the credentials, when known) and the `timestamp`. Either a full log name such as `projects/my-project/logs/site-verification`, or a log ID written in the project of the credentials. The credentials need the `https://www.googleapis.com/auth/logging.write` scope and the `logging.logEntries.create` permission. Failing to write an entry is logged, but never fails the apply. Disabled if not provided.",
+			},
+			proxyURLKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https", "socks5"}),
+				Description:  "The URL of a proxy to send the API calls and the token requests of the credentials through. Hosts listed in the `NO_PROXY` environment variable are still reached directly. If not provided, the `HTTPS_PROXY` environment variable is used.",
+			},
+			localAddressKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsIPAddress,
+				Description:  "The local IP address to send the API calls from, for hosts with several network interfaces.",
+			},
+			caBundleKey: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Either the path to or the contents of a PEM bundle of CA certificates to trust instead of the system ones, e.g. behind a TLS inspecting proxy. It applies to the token requests of the credentials as well as to the API calls.",
+			},
+			insecureSkipVerifyKey: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to skip the verification of the API's TLS certificates. This is insecure and only meant for testing: prefer `ca_bundle`.",
+			},
+			requestTimeoutKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+				Description:  "How long a single API call may take, reading the response included, e.g. `30s`, so that a hung connection fails the call like a network error instead of blocking the run. The verifications retry such failures within their create timeout. No limit if not set. Whatever this setting, the API calls and the waits between their retries are cancelled when Terraform is interrupted, and the calls are then not retried.",
+			},
+			maxConcurrentRequestsKey: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "How many API calls the provider may make at the same time, all resources and data sources together, e.g. `4` to stay within the rate limits when Terraform reads many tokens concurrently. The other calls wait for their turn, within their timeouts. Unlimited if 0.",
 			},
 		},
-		ConfigureFunc: configureProvider,
 		DataSourcesMap: map[string]*schema.Resource{
 			"googlesiteverification_dns_token": {
 				Schema: map[string]*schema.Schema{
 					domainKey: {
+						Type:         schema.TypeString,
+						Required:     true,
+						ValidateFunc: validateIdentifier,
+						Description:  "The domain you want to verify, e.g. `example.com`, without scheme, path nor trailing slash. The URL of the site or app for the other `site_type`s.",
+					},
+					methodKey: {
+						Type:         schema.TypeString,
+						Optional:     true,
+						Default:      defaultVerificationMethod,
+						ValidateFunc: validation.StringInSlice(verificationMethods, false),
+						Description:  "The verification method you want a token for: `DNS_TXT` or `DNS_CNAME` for domains, or another method for the other `site_type`s.",
+					},
+					siteTypeKey: {
+						Type:         schema.TypeString,
+						Optional:     true,
+						ValidateFunc: validation.StringInSlice(webResourceTypes, false),
+						Description:  "The type of web resource to verify: `INET_DOMAIN`, `SITE` or `ANDROID_APP`. Defaults to `INET_DOMAIN` for the DNS methods, and `SITE` for the others. The `record_*` attributes are only set for domains.",
+					},
+					propertyTypeKey: {
+						Type:          schema.TypeString,
+						Optional:      true,
+						ValidateFunc:  validation.StringInSlice(propertyTypes, false),
+						ConflictsWith: []string{siteTypeKey},
+						Description:   "The type of Search Console property `domain` holds, instead of `site_type`: `DOMAIN`, e.g. `sc-domain:example.com`, verified as the `INET_DOMAIN` `example.com`, or `URL_PREFIX`, e.g. `http://192.0.2.1/`, verified as a `SITE`. The `sc-domain:` form is recognized without it.",
+					},
+					verifyExistingKey: {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "Whether to also check if the domain is already verified by the provider's credentials, and set `already_verified`. Google hands out tokens for any domain, typos included, so this is the way to skip the DNS changes of the domains which are done.",
+					},
+					alreadyVerifiedKey: {
+						Type:        schema.TypeBool,
+						Computed:    true,
+						Description: "Whether the domain is already verified by the provider's credentials. Only checked with `verify_existing`, false otherwise.",
+					},
+					tokenKey: {
 						Type:        schema.TypeString,
-						Required:    true,
-						Description: "The domain you want to verify.",
+						Computed:    true,
+						Description: "The token as Google returns it, to give to the `googlesiteverification_dns` resource. For the `DNS_TXT` method, it is the same as `record_value`; for `DNS_CNAME`, it holds both the name and the value of the record.",
+					},
+					tokenMethodKey: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The verification method the token was requested for, exactly as sent to the API, e.g. to check in `terraform console` that a failing verification uses the token of the right method.",
+					},
+					tokenTypeKey: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The type of web resource the token was requested for, exactly as sent to the API: `INET_DOMAIN`, `SITE` or `ANDROID_APP`, whether it was configured with `site_type`, `property_type`, or defaulted from the method.",
 					},
 					recordTypeKey: {
 						Type:        schema.TypeString,
 						Computed:    true,
-						Description: "The type of DNS record you should create.",
+						Description: "The type of DNS record you should create: `TXT` or `CNAME`, depending on the method.",
+					},
+					recordNameOverrideKey: {
+						Type:         schema.TypeString,
+						Optional:     true,
+						ValidateFunc: validation.StringMatch(recordNameRegexp, "expected a DNS name such as _verification.example.com"),
+						Description:  "Where the TXT record actually lives when it is not on the domain itself, e.g. the target of a CNAME delegating the domain's records in a split-horizon setup. Google still looks the token up on the domain: the name must be where that lookup ends. Only for the `DNS_TXT` method, the `DNS_CNAME` token telling its own record name.",
 					},
 					recordNameKey: {
 						Type:        schema.TypeString,
 						Computed:    true,
-						Description: "The name of the record you should create.",
+						Description: "The name of the record you should create: the domain, its `record_name_override`, or the name the `DNS_CNAME` token tells.",
 					},
 					recordValueKey: {
 						Type:        schema.TypeString,
 						Computed:    true,
-						Description: "The value of the record you should create.",
+						Description: "The value of the record you should create: the token for the `DNS_TXT` method, the target host the `DNS_CNAME` token tells, without its trailing dot, for the `DNS_CNAME` method.",
+					},
+					recordValueStringsKey: {
+						Type:        schema.TypeList,
+						Computed:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+						Description: "The value of the record split into character-strings of at most 255 bytes, as TXT records require. For the DNS providers which expect long values to be split beforehand. A single string for the `DNS_CNAME` method.",
 					},
 				},
-				Description: "https://developers.google.com/site-verification/v1/webResource/getToken",
+				Description: "https://developers.google.com/site-verification/v1/webResource/getToken\n\nGoogle does not expose any expiry date for the tokens. See the `token_stale` attribute of the `googlesiteverification_dns` resource to know when a token changed.\n\nThe rate limiting and server errors are retried within the read timeout, 2 minutes by default, the other errors fail right away.",
 				Read:        readDnsSiteVerificationToken,
+				Timeouts: &schema.ResourceTimeout{
+					Read: schema.DefaultTimeout(transientErrorTimeout),
+				},
 			},
+			"googlesiteverification_dns_records":    dnsRecordsDataSource(),
+			"googlesiteverification_drift":          driftDataSource(),
+			"googlesiteverification_dns_tokens":     dnsTokensDataSource(),
+			"googlesiteverification_inventory":      inventoryDataSource(),
+			"googlesiteverification_auth_check":     authCheckDataSource(),
+			"googlesiteverification_summary":        summaryDataSource(),
+			"googlesiteverification_meta_token":     metaTokenDataSource(),
+			"googlesiteverification_file_token":     fileTokenDataSource(),
+			"googlesiteverification_sites":          sitesDataSource(),
+			"googlesiteverification_dns":            dnsDataSource(),
+			"googlesiteverification_status":         statusDataSource(),
+			"googlesiteverification_token":          tokenDataSource(),
+			"googlesiteverification_existing_token": existingTokenDataSource(),
+			"googlesiteverification_project":        projectDataSource(),
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"googlesiteverification_dns": {
 				Schema: map[string]*schema.Schema{
 					domainKey: {
-						Type:        schema.TypeString,
-						Required:    true,
-						ForceNew:    true,
-						Description: "The domain you want to verify.",
+						Type:             schema.TypeString,
+						Required:         true,
+						ForceNew:         true,
+						ValidateFunc:     validateIdentifier,
+						DiffSuppressFunc: suppressEquivalentSiteURL,
+						Description:      "The domain you want to verify, e.g. `example.com`, without scheme, path nor trailing slash. The URL of the site or app for the other `site_type`s, a site URL being verified in the form Google stores it in, e.g. `https://example.com/` for `https://Example.com:443`.",
 					},
 					tokenKey: {
 						Type:        schema.TypeString,
 						Required:    true,
-						ForceNew:    true,
-						Description: "The token you got from data.googlesiteverification_dns_token. This forces a new verification in case the token changes.",
+						Description: "The token you got from data.googlesiteverification_dns_token, i.e. its `token` attribute (or its `record_value` for the `DNS_TXT` method). When it changes, the domain is verified again with the new token, without being unverified first: if the new token can't be verified, the previous verification stays.",
+					},
+					methodKey: {
+						Type:         schema.TypeString,
+						Optional:     true,
+						ForceNew:     true,
+						Default:      defaultVerificationMethod,
+						ValidateFunc: validation.StringInSlice(verificationMethods, false),
+						Description:  "The verification method the token was obtained with: `DNS_TXT` or `DNS_CNAME` for domains, or another method for the other `site_type`s.",
+					},
+					methodsKey: {
+						Type:          schema.TypeList,
+						Optional:      true,
+						ForceNew:      true,
+						MinItems:      1,
+						Elem:          &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringInSlice(verificationMethods, false)},
+						ConflictsWith: []string{methodKey},
+						Description:   "Several verification methods to verify with, instead of `method`, e.g. `[\"DNS_TXT\", \"DNS_CNAME\"]` for the verification to survive the removal of either record. The verification is done with each of them in turn, and `token` is the token of the first one. Google does not tell which methods a verification holds, so they are not read back, and the destruction unverifies the web resource as a whole. The methods must all apply to the same `site_type`: a domain and a site URL are separate web resources, use a `googlesiteverification_site` resource for the latter.",
+					},
+					siteTypeKey: {
+						Type:         schema.TypeString,
+						Optional:     true,
+						Computed:     true,
+						ForceNew:     true,
+						ValidateFunc: validation.StringInSlice(webResourceTypes, false),
+						Description:  "The type of web resource to verify: `INET_DOMAIN`, `SITE` or `ANDROID_APP`, in which case `domain` holds the identifier of the site or app. Defaults to `INET_DOMAIN` for the DNS methods, and `SITE` for the others.",
+					},
+					propertyTypeKey: {
+						Type:          schema.TypeString,
+						Optional:      true,
+						ForceNew:      true,
+						ValidateFunc:  validation.StringInSlice(propertyTypes, false),
+						ConflictsWith: []string{siteTypeKey},
+						Description:   "The type of Search Console property `domain` holds, instead of `site_type`: `DOMAIN`, e.g. `sc-domain:example.com`, verified as the `INET_DOMAIN` `example.com`, or `URL_PREFIX`, e.g. `http://192.0.2.1/`, verified as a `SITE`. The `sc-domain:` form is recognized without it.",
+					},
+					lastStatusCodeKey: {
+						Type:        schema.TypeInt,
+						Computed:    true,
+						Description: "The HTTP status code of the last successful read of the verification. Purely informational, for debugging.",
+					},
+					displayIDKey: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "A human friendly version of the id, without the `dns://` prefix the API uses.",
+					},
+					webResourceIDKey: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The id of the web resource, as the API uses it, e.g. `dns://example.com`, for the tools which reference web resources by id.",
+					},
+					verificationTriggersKey: {
+						Type:        schema.TypeMap,
+						Optional:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+						Description: "Arbitrary values which make the domain be verified again, without unverifying it first, whenever they change. For example the id of the DNS record holding the token.",
+					},
+					ownersKey: {
+						Type:        schema.TypeList,
+						Optional:    true,
+						Computed:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validateOwnerEmail},
+						Description: "The emails of the owners of the verified domain. If provided, the owners are updated to match it, without verifying the domain again: owners can be delegated to or removed. The list must keep the identity of the provider's credentials, so that it can still manage the domain. Duplicates, whatever their case, are only sent once. Read from Google if not provided.",
+					},
+					includeWwwKey: {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Description: "Whether to also verify the `www.` variant of the domain, e.g. `www.example.com` for `example.com`, as a separate `INET_DOMAIN` web resource, instead of duplicating the resource. Its record is not checked: get its token with data.googlesiteverification_dns_token, the verification being retried until Google sees it. Both are unverified on destroy, and the variant alone when this is turned off. The owners only apply to the domain itself. Defaults to false.",
+					},
+					verifiedIDsKey: {
+						Type:        schema.TypeMap,
+						Computed:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+						Description: "The ids of the verified web resources by domain: the domain and, with `include_www`, its `www.` variant.",
+					},
+					newlyVerifiedKey: {
+						Type:        schema.TypeBool,
+						Computed:    true,
+						Description: "Whether the creation actually verified the web resource, rather than finding it already verified by the provider's credentials, e.g. to only notify about the new verifications. True when that could not be checked, e.g. with the `siteverification.verify_only` scope.",
+					},
+					ownerCountKey: {
+						Type:        schema.TypeInt,
+						Computed:    true,
+						Description: "How many owners the verified domain has, e.g. to alert on unexpected additional owners.",
+					},
+					primaryOwnerKey: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The identity of the provider's credentials if it is one of the owners, the first owner otherwise. Empty if Google returns no owner.",
+					},
+					maxOwnersKey: {
+						Type:         schema.TypeInt,
+						Optional:     true,
+						ValidateFunc: validation.IntAtLeast(1),
+						Description:  "The maximum number of owners the verified domain may have. Owners above it are reported in `owner_policy_violations`.",
+					},
+					disallowedOwnersKey: {
+						Type:        schema.TypeSet,
+						Optional:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+						Description: "Emails which must not own the verified domain. They are reported in `owner_policy_violations`.",
+					},
+					remediateOwnersKey: {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "Whether to remove, on every read, the owners breaking the `max_owners` and `disallowed_owners` policy, instead of only reporting them. The service account of the provider's credentials is never removed, and nothing is removed when it cannot be determined (e.g. with user credentials).",
+					},
+					ownerPolicyViolationsKey: {
+						Type:        schema.TypeList,
+						Computed:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString},
+						Description: "The owners breaking the `max_owners` and `disallowed_owners` policy, as of the last read.",
+					},
+					resultKey: resultSchema(),
+					tokenStaleKey: {
+						Type:        schema.TypeBool,
+						Computed:    true,
+						Description: "Whether Google now hands out a different token for the domain than the one it was verified with. Tokens have no expiry date, so this is the way to know when the DNS record should be refreshed. Only checked with `check_token_stale`, false otherwise.",
+					},
+					checkTokenStaleKey: {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Description: "Whether to check on every refresh if the token is stale, and set `token_stale`. This costs a token request per refresh on top of the read. Defaults to false.",
+					},
+					verifiedTokenKey: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The token Google handed out for the domain when it was verified (or imported), with the first of its methods. Unlike `token`, which is whatever was configured, this is the value the DNS record had to hold.",
+					},
+					waitForSearchConsoleKey: {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "Whether to wait (up to 5 minutes) after verifying for the domain to show up as a property in [Search Console](https://search.google.com/search-console), and check it again on every read. This needs the credentials to have the `https://www.googleapis.com/auth/webmasters.readonly` scope. Failing to reach Search Console never fails the verification.",
+					},
+					searchConsoleReadyKey: {
+						Type:        schema.TypeBool,
+						Computed:    true,
+						Description: "Whether Search Console knows the domain property, when `wait_for_search_console` is enabled.",
+					},
+					manualVerifyCommandStyleKey: {
+						Type:         schema.TypeString,
+						Optional:     true,
+						Default:      "gcloud",
+						ValidateFunc: validation.StringInSlice([]string{"gcloud", "curl"}, false),
+						Description:  "How `manual_verify_command` gets an access token: `gcloud` runs `gcloud auth print-access-token`, `curl` expects it in the `ACCESS_TOKEN` environment variable.",
+					},
+					manualVerifyCommandKey: {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "A shell command reproducing the verification API call, to debug it outside of Terraform. It never contains any credentials.",
+					},
+					dnsCheckKey: {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "Whether to look up the verification DNS record: on creation, the verification is only attempted once the record can be resolved, which saves the API calls bound to fail while it propagates; on every read, a warning is logged when it can't be found anymore, which never fails the plan.",
+					},
+					dnsCheckServersKey: {
+						Type:        schema.TypeList,
+						Optional:    true,
+						Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.NoZeroValues},
+						Description: "The DNS servers to look up the verification record with, e.g. `[\"8.8.8.8\", \"1.1.1.1:53\"]`, queried in turn, instead of the system's resolver. Querying public resolvers tells better when Google will see the record than a local cache. Also used by `delete_wait_for_record_removal`.",
+					},
+					deleteWaitForRecordRemovalKey: {
+						Type:        schema.TypeBool,
+						Optional:    true,
+						Default:     false,
+						Description: "Whether to wait, before unverifying the domain, until the verification DNS record can't be resolved anymore. Google refuses to unverify a domain while it still sees the token, so this makes the wait explicit in the logs rather than relying on the API's retried errors.",
 					},
 				},
-				Create:      createDnsSiteVerification,
-				Read:        readDnsSiteVerification,
-				Delete:      deleteDnsSiteVerification,
-				Description: "https://developers.google.com/site-verification",
+				Create:        createDnsSiteVerification,
+				Read:          readDnsSiteVerification,
+				Update:        updateDnsSiteVerification,
+				Delete:        deleteDnsSiteVerification,
+				CustomizeDiff: customizeDnsSiteVerificationDiff,
+				Description:   "https://developers.google.com/site-verification",
 				Timeouts: &schema.ResourceTimeout{
 					Create: schema.DefaultTimeout(60 * time.Minute),
+					Update: schema.DefaultTimeout(60 * time.Minute),
+					Delete: schema.DefaultTimeout(20 * time.Minute),
 				},
 				Importer: &schema.ResourceImporter{
 					State: importSiteVerification,
 				},
+				SchemaVersion: 1,
+				StateUpgraders: []schema.StateUpgrader{
+					{
+						Version: 0,
+						Type:    dnsSiteVerificationV0().CoreConfigSchema().ImpliedType(),
+						Upgrade: upgradeDnsSiteVerificationV0,
+					},
+				},
+			},
+			"googlesiteverification_dns_domains": dnsDomainsSiteVerificationResource(),
+			"googlesiteverification_dns_batch":   dnsBatchSiteVerificationResource(),
+			"googlesiteverification_dns_managed": dnsManagedSiteVerificationResource(),
+			"googlesiteverification_dns_monitor": dnsMonitorResource(),
+			"googlesiteverification_site":        siteResource(),
+		},
+	}
+	describeAPIErrors(provider)
+	provider.ConfigureFunc = func(resourceData *schema.ResourceData) (interface{}, error) {
+		// the Terraform version is only known once the provider is configured
+		return configureProvider(resourceData, provider.TerraformVersion, provider.StopContext())
+	}
+	return provider
+}
+
+// userAgent identifies the provider and the Terraform version it runs in to the APIs.
+func userAgent(terraformVersion string) string {
+	return fmt.Sprintf("Terraform/%s (+https://www.terraform.io) terraform-provider-googlesiteverification/%s", terraformVersion, version)
+}
+
+// dnsSiteVerificationV0 is the googlesiteverification_dns resource as the provider 0.3.1 and earlier stored it.
+func dnsSiteVerificationV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainKey: {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			tokenKey: {
+				Type:     schema.TypeString,
+				Required: true,
 			},
 		},
 	}
 }
 
+// upgradeDnsSiteVerificationV0 prefixes the ids of the domains with dns://:
+// the provider 0.3.1 and earlier stored the bare domain as the id, which is incorrect.
+func upgradeDnsSiteVerificationV0(rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+	if id, ok := rawState[idKey].(string); ok && id != "" && !strings.Contains(id, "://") {
+		rawState[idKey] = fmt.Sprintf("dns://%s", id)
+	}
+	return rawState, nil
+}
+
 func importSiteVerification(resourceData *schema.ResourceData, provider interface{}) ([]*schema.ResourceData, error) {
-	service := provider.(configuredProvider).service
+	// both example.com and dns://example.com are accepted, the API only knows the latter,
+	// and so are the site URLs and the sc-domain: properties, in any of their forms
 	domain := strings.TrimPrefix(resourceData.Id(), "dns://")
-
 	if setErr := resourceData.Set(domainKey, domain); setErr != nil {
 		return nil, setErr
 	}
+	identifier := resourceIdentifier(resourceData)
+	resourceData.SetId(webResourceID(identifier))
 
-	_, getErr := service.WebResource.Get(resourceData.Id()).Do()
+	webResource, getErr := getWebResource(provider.(configuredProvider), resourceData.Id())
 	if getErr != nil {
+		if httpStatusCode(getErr) == http.StatusNotFound {
+			return nil, fmt.Errorf("cannot import %s: the web resource is not verified by the provider's credentials", domain)
+		}
 		return nil, getErr
 	}
+	if setErr := resourceData.Set(lastStatusCodeKey, webResource.HTTPStatusCode); setErr != nil {
+		return nil, setErr
+	}
 
-	// fetch and set the token's value
-	tokenResource, getTokenErr := service.WebResource.GetToken(&siteverification.SiteVerificationWebResourceGettokenRequest{
-		Site: &siteverification.SiteVerificationWebResourceGettokenRequestSite{
-			Identifier: domain,
-			Type:       siteType,
-		},
-		VerificationMethod: verificationMethod,
-	}).Do()
-	if getTokenErr != nil {
-		return nil, getTokenErr
+	webResourceType := siteType
+	if webResource.Site != nil && webResource.Site.Type != "" {
+		webResourceType = webResource.Site.Type
+	}
+	if setErr := resourceData.Set(siteTypeKey, webResourceType); setErr != nil {
+		return nil, setErr
+	}
+	// the API does not tell which method a resource was verified with
+	method := defaultMethodOf(webResourceType)
+	if setErr := resourceData.Set(methodKey, method); setErr != nil {
+		return nil, setErr
+	}
+
+	token, tokenErr := setVerifiedToken(resourceData, provider.(configuredProvider), webResourceType, identifier, method)
+	if tokenErr != nil {
+		return nil, tokenErr
 	}
-	if setErr := resourceData.Set(tokenKey, tokenResource.Token); setErr != nil {
+	if setErr := resourceData.Set(tokenKey, token); setErr != nil {
 		return nil, setErr
 	}
 
 	return []*schema.ResourceData{resourceData}, nil
 }
 
+// defaultMethodOf returns the verification method assumed for a web resource of the given type when none is known:
+// DNS_TXT for the domains, META for the sites and apps.
+func defaultMethodOf(webResourceType string) string {
+	if webResourceType == siteType {
+		return defaultVerificationMethod
+	}
+	return "META"
+}
+
 type configuredProvider struct {
-	service *siteverification.Service
+	service       *siteverification.Service
+	searchConsole *webmasters.Service
+	// cloudDNS returns the Cloud DNS client, built on first use
+	cloudDNS   func() (*dns.Service, error)
+	backoff    backoff
+	waitForIAM bool
+	// allowedMethods is empty when all the methods are allowed
+	allowedMethods     []string
+	metrics            *metricsRecorder
+	ownerChangeWebhook string
+	auditLog           *auditLogger
+	// deleteRetryOn are the extra substrings of the error messages to retry the unverifications on
+	deleteRetryOn       []string
+	skipReadAfterCreate bool
+	// preflight is nil when the preflight check is disabled
+	preflight *preflight
+	// managingIdentity is the email of the credentials' service account, empty when unknown
+	managingIdentity string
+	readOnly         bool
+	// maxRetries caps the retries of the verifications and unverifications, 0 for no cap
+	maxRetries int
+	// project is the project the credentials resolve to
+	project credentialsProject
+	// stopContext is cancelled when Terraform is interrupted, nil if it can't be
+	stopContext context.Context
+	// requestTimeout bounds each API call, 0 for no limit
+	requestTimeout time.Duration
+}
+
+// requestContext returns the context of a single API call: cancelled when Terraform is interrupted,
+// and after the request_timeout if any.
+func (provider configuredProvider) requestContext() (context.Context, context.CancelFunc) {
+	ctx := provider.stopContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if provider.requestTimeout > 0 {
+		return context.WithTimeout(ctx, provider.requestTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// interrupted tells whether Terraform was interrupted, in which case nothing should be retried.
+func (provider configuredProvider) interrupted() bool {
+	return provider.stopContext != nil && provider.stopContext.Err() != nil
+}
+
+// checkWritable returns an error if the provider's configuration is read-only, the action being the change refused.
+func (provider configuredProvider) checkWritable(action string) error {
+	if provider.readOnly {
+		return fmt.Errorf("refusing to %s: the provider is configured with %s = true", action, readOnlyKey)
+	}
+	return nil
+}
+
+// retriesExhausted tells whether a verification or unverification which failed its given attempt (starting at 1)
+// has used all the retries the provider's configuration allows.
+func (provider configuredProvider) retriesExhausted(operation string, target string, attempts int) bool {
+	if provider.maxRetries == 0 || attempts <= provider.maxRetries {
+		return false
+	}
+	log.Printf("[WARN] giving up the %s of %s after %d attempts, as %s = %d", operation, target, attempts, maxRetriesKey, provider.maxRetries)
+	return true
 }
 
-func configureProvider(resourceData *schema.ResourceData) (interface{}, error) {
-	ctx := context.Background()
+// checkMethodAllowed returns an error if the provider's configuration forbids the verification method.
+func (provider configuredProvider) checkMethodAllowed(method string) error {
+	if len(provider.allowedMethods) == 0 {
+		return nil
+	}
+	for _, allowedMethod := range provider.allowedMethods {
+		if method == allowedMethod {
+			return nil
+		}
+	}
+	return fmt.Errorf("the %s verification method is not allowed by the provider configuration, only %s are", method, strings.Join(provider.allowedMethods, ", "))
+}
 
-	credentialsClientOption, crendentialsErr := findCredentials(resourceData, ctx)
+// configureProvider builds the configured provider. stopContext is cancelled when Terraform is interrupted.
+func configureProvider(resourceData *schema.ResourceData, terraformVersion string, stopContext context.Context) (interface{}, error) {
+	transport, customized, transportErr := baseTransport(resourceData)
+	if transportErr != nil {
+		return nil, transportErr
+	}
+	var base http.RoundTripper = transport
+	if maxConcurrentRequests := resourceData.Get(maxConcurrentRequestsKey).(int); maxConcurrentRequests > 0 {
+		base = newThrottledTransport(transport, maxConcurrentRequests)
+		customized = true
+	}
+
+	// the credentials get their tokens through the same transport as the API calls,
+	// so that the network settings apply to the token endpoints too
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: base})
+
+	credentials, crendentialsErr := findCredentials(resourceData, ctx)
 	if crendentialsErr != nil {
 		return nil, crendentialsErr
 	}
 
-	service, serviceErr := siteverification.NewService(ctx, credentialsClientOption)
+	if resourceData.Get(validateCredentialsKey).(bool) {
+		if validateErr := validateCredentials(credentials); validateErr != nil {
+			return nil, validateErr
+		}
+	}
+	// the value has already been validated by the schema
+	requestTimeout, _ := time.ParseDuration(resourceData.Get(requestTimeoutKey).(string))
+
+	// clientOptionsFor returns the options of the API clients authenticated with the given credentials
+	clientOptionsFor := func(credentials *google.Credentials) ([]option.ClientOption, error) {
+		clientOptions := []option.ClientOption{option.WithCredentials(credentials), option.WithUserAgent(userAgent(terraformVersion))}
+		// the options the authenticated transport is built from
+		authClientOptions := clientOptions
+		if billingProject := resourceData.Get(billingProjectKey).(string); billingProject != "" {
+			authClientOptions = append(append([]option.ClientOption{}, clientOptions...), option.WithQuotaProject(billingProject))
+		}
+		if !customized && requestTimeout == 0 {
+			return authClientOptions, nil
+		}
+		httpClient, httpClientErr := newHTTPClient(ctx, base, authClientOptions...)
+		if httpClientErr != nil {
+			return nil, httpClientErr
+		}
+		httpClient.Timeout = requestTimeout
+		// the HTTP client already sends the quota project, which the API clients refuse along with their own HTTP client
+		return append(clientOptions, option.WithHTTPClient(httpClient)), nil
+	}
+	clientOptions, clientOptionsErr := clientOptionsFor(credentials)
+	if clientOptionsErr != nil {
+		return nil, clientOptionsErr
+	}
+
+	serviceOptions := clientOptions
+	if endpoint := resourceData.Get(endpointKey).(string); endpoint != "" {
+		serviceOptions = append(append([]option.ClientOption{}, clientOptions...), option.WithEndpoint(endpoint))
+	}
+	service, serviceErr := siteverification.NewService(ctx, serviceOptions...)
 	if serviceErr != nil {
 		return nil, serviceErr
 	}
 
+	if resourceData.Get(validateCredentialsKey).(bool) && !verifyOnlyScopes(resourceData) {
+		if validateErr := validateAPIAccess(stopContext, service); validateErr != nil {
+			return nil, validateErr
+		}
+	}
+
+	searchConsole, searchConsoleErr := webmasters.NewService(ctx, clientOptions...)
+	if searchConsoleErr != nil {
+		return nil, searchConsoleErr
+	}
+	var cloudDNSOnce sync.Once
+	var cloudDNS *dns.Service
+	var cloudDNSErr error
+	newCloudDNS := func() (*dns.Service, error) {
+		cloudDNSOnce.Do(func() {
+			dnsCredentials, dnsCredentialsErr := findCredentialsWithScopes(resourceData, ctx, cloudDNSScopes)
+			if dnsCredentialsErr != nil {
+				cloudDNSErr = fmt.Errorf("finding the credentials of Cloud DNS: %w", dnsCredentialsErr)
+				return
+			}
+			dnsClientOptions, dnsClientOptionsErr := clientOptionsFor(dnsCredentials)
+			if dnsClientOptionsErr != nil {
+				cloudDNSErr = dnsClientOptionsErr
+				return
+			}
+			cloudDNS, cloudDNSErr = dns.NewService(ctx, dnsClientOptions...)
+		})
+		return cloudDNS, cloudDNSErr
+	}
+
+	project := credentialsProjects(credentials)
+	if project.ProjectID != "" {
+		log.Printf("[INFO] the credentials resolve to the project %s", project.ProjectID)
+	}
+
+	managingIdentity := credentialsEmail(credentials)
+	if targetServiceAccount := resourceData.Get(impersonateServiceAccountKey).(string); targetServiceAccount != "" {
+		managingIdentity = targetServiceAccount
+	}
+
+	auditLog, auditLogErr := newAuditLogger(ctx, resourceData.Get(auditLogNameKey).(string), credentials, managingIdentity, clientOptions)
+	if auditLogErr != nil {
+		return nil, auditLogErr
+	}
+
+	// the values have already been validated by the schema
+	baseDelay, _ := time.ParseDuration(resourceData.Get(retryBaseDelayKey).(string))
+	maxDelay, _ := time.ParseDuration(resourceData.Get(retryMaxDelayKey).(string))
+
+	var allowedMethods []string
+	for _, method := range resourceData.Get(allowedMethodsKey).(*schema.Set).List() {
+		allowedMethods = append(allowedMethods, method.(string))
+	}
+	sort.Strings(allowedMethods)
+
+	preflightCheck := resourceData.Get(preflightCheckKey).(bool)
+	if preflightCheck && verifyOnlyScopes(resourceData) {
+		log.Printf("[INFO] skipping the preflight check: the configured scopes are refused the listing it relies on")
+		preflightCheck = false
+	}
+
+	var deleteRetryOn []string
+	for _, substring := range resourceData.Get(deleteRetryOnKey).([]interface{}) {
+		deleteRetryOn = append(deleteRetryOn, substring.(string))
+	}
+
 	return configuredProvider{
-		service: service,
+		service:       service,
+		searchConsole: searchConsole,
+		cloudDNS:      newCloudDNS,
+		backoff: backoff{
+			baseDelay:  baseDelay,
+			multiplier: resourceData.Get(retryMultiplierKey).(float64),
+			maxDelay:   maxDelay,
+			jitter:     resourceData.Get(retryJitterKey).(float64),
+			pauseFile:  resourceData.Get(retryPauseFileKey).(string),
+			disabled:   resourceData.Get(disableRetriesKey).(bool),
+			stop:       stopContext,
+		},
+		waitForIAM:          resourceData.Get(waitForIAMKey).(bool),
+		allowedMethods:      allowedMethods,
+		metrics:             newMetricsRecorder(resourceData.Get(metricsFileKey).(string)),
+		ownerChangeWebhook:  resourceData.Get(ownerChangeWebhookKey).(string),
+		auditLog:            auditLog,
+		preflight:           newPreflight(preflightCheck),
+		managingIdentity:    managingIdentity,
+		deleteRetryOn:       deleteRetryOn,
+		skipReadAfterCreate: resourceData.Get(skipReadAfterCreateKey).(bool),
+		readOnly:            resourceData.Get(readOnlyKey).(bool),
+		maxRetries:          resourceData.Get(maxRetriesKey).(int),
+		project:             project,
+		stopContext:         stopContext,
+		requestTimeout:      requestTimeout,
 	}, nil
 }
 
-func findCredentials(resourceData *schema.ResourceData, ctx context.Context) (option.ClientOption, error) {
+func findCredentials(resourceData *schema.ResourceData, ctx context.Context) (*google.Credentials, error) {
 	// here we are trying to match the official GCP Provider's behavior https://www.terraform.io/docs/providers/google/guides/provider_reference.html#full-reference
 	var credentialsLiteral string
 	if credentialsFromConfig, ok := resourceData.GetOk(credentialsKey); ok {
 		credentialsLiteral = credentialsFromConfig.(string)
 	}
+	accessToken := resourceData.Get(accessTokenKey).(string)
+	if credentialsLiteral != "" && accessToken != "" {
+		return nil, fmt.Errorf("only one of %s and %s can be set (including through their environment variables)", credentialsKey, accessTokenKey)
+	}
+
+	scopes := oauthScopes
+	if resourceData.Get(auditLogNameKey).(string) != "" {
+		scopes = append(append([]string{}, oauthScopes...), logging.LoggingWriteScope)
+	}
+	if configuredScopes := resourceData.Get(scopesKey).([]interface{}); len(configuredScopes) > 0 {
+		scopes = make([]string, len(configuredScopes))
+		for i, scope := range configuredScopes {
+			scopes[i] = scope.(string)
+		}
+	}
+	return findCredentialsWithScopes(resourceData, ctx, scopes)
+}
+
+// findCredentialsWithScopes finds the credentials like findCredentials, requesting the given scopes.
+func findCredentialsWithScopes(resourceData *schema.ResourceData, ctx context.Context, scopes []string) (*google.Credentials, error) {
+	var credentialsLiteral string
+	if credentialsFromConfig, ok := resourceData.GetOk(credentialsKey); ok {
+		credentialsLiteral = credentialsFromConfig.(string)
+	}
+	accessToken := resourceData.Get(accessTokenKey).(string)
+
+	targetServiceAccount := resourceData.Get(impersonateServiceAccountKey).(string)
+	if targetServiceAccount == "" {
+		return baseCredentials(ctx, credentialsLiteral, accessToken, scopes)
+	}
 
-	var credentialsClientOption option.ClientOption
+	// the base credentials only need to be allowed to impersonate, the target service account gets the scopes
+	credentials, credentialsErr := baseCredentials(ctx, credentialsLiteral, accessToken, []string{iamcredentials.CloudPlatformScope})
+	if credentialsErr != nil {
+		return nil, credentialsErr
+	}
+	return impersonatedCredentials(ctx, credentials, targetServiceAccount, impersonationDelegates(resourceData), scopes)
+}
+
+// baseCredentials returns the credentials from the credentials or access_token attribute,
+// or the application default credentials.
+func baseCredentials(ctx context.Context, credentialsLiteral string, accessToken string, scopes []string) (*google.Credentials, error) {
+	if accessToken != "" {
+		// the scopes are the ones the token was minted with
+		return &google.Credentials{
+			TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken, TokenType: "Bearer"}),
+		}, nil
+	}
 	if credentialsLiteral != "" {
-		if json.Valid([]byte(credentialsLiteral)) {
-			credentialsClientOption = option.WithCredentialsJSON([]byte(credentialsLiteral))
-		} else {
-			_, statErr := os.Stat(credentialsLiteral)
-			if statErr != nil {
-				return nil, statErr
+		credentialsJSON := []byte(credentialsLiteral)
+		if strings.HasPrefix(credentialsLiteral, secretManagerPrefix) {
+			var secretErr error
+			credentialsJSON, secretErr = secretManagerCredentialsJSON(ctx, credentialsLiteral)
+			if secretErr != nil {
+				return nil, secretErr
+			}
+		} else if decoded, ok := base64CredentialsJSON(credentialsLiteral); ok {
+			credentialsJSON = decoded
+		} else if !json.Valid(credentialsJSON) {
+			var readErr error
+			credentialsJSON, readErr = os.ReadFile(credentialsLiteral)
+			if readErr != nil {
+				return nil, readErr
 			}
-			credentialsClientOption = option.WithCredentialsFile(credentialsLiteral)
 		}
-	} else {
-		credentials, defaultCredentialsErr := google.FindDefaultCredentials(ctx)
-		if defaultCredentialsErr != nil {
-			return nil, defaultCredentialsErr
+		if isExternalAccount(credentialsJSON) {
+			return externalAccountCredentials(ctx, credentialsJSON, scopes)
+		}
+		return google.CredentialsFromJSON(ctx, credentialsJSON, scopes...)
+	}
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		// the application default credentials don't know the external accounts either
+		if credentialsJSON, readErr := os.ReadFile(path); readErr == nil && isExternalAccount(credentialsJSON) {
+			return externalAccountCredentials(ctx, credentialsJSON, scopes)
 		}
-		credentialsClientOption = option.WithCredentials(credentials)
 	}
-	return credentialsClientOption, nil
+	return google.FindDefaultCredentials(ctx, scopes...)
 }
 
 func readDnsSiteVerificationToken(resourceData *schema.ResourceData, provider interface{}) error {
-	service := provider.(configuredProvider).service
-	domain := resourceData.Get(domainKey).(string)
+	if _, _, propertyErr := propertyIdentifier(resourceData.Get(propertyTypeKey).(string), resourceData.Get(domainKey).(string)); propertyErr != nil {
+		return propertyErr
+	}
+	domain := resourceIdentifier(resourceData)
+	method := resourceData.Get(methodKey).(string)
+	if _, ok := resourceData.GetOk(recordNameOverrideKey); ok && method != "DNS_TXT" {
+		return fmt.Errorf("%s only applies to the DNS_TXT method, the %s token tells its own record name", recordNameOverrideKey, method)
+	}
+	webResourceType := resourceSiteType(resourceData, method)
+	if typeErr := checkWebResourceType(webResourceType, method); typeErr != nil {
+		return typeErr
+	}
+	if identifierErr := checkIdentifier(webResourceType, domain); identifierErr != nil {
+		return identifierErr
+	}
 
-	tokenResource, getTokenErr := service.WebResource.GetToken(&siteverification.SiteVerificationWebResourceGettokenRequest{
-		Site: &siteverification.SiteVerificationWebResourceGettokenRequestSite{
-			Identifier: domain,
-			Type:       siteType,
-		},
-		VerificationMethod: verificationMethod,
-	}).Do()
+	token, getTokenErr := getTokenWithin(provider.(configuredProvider), resourceData.Timeout(schema.TimeoutRead), webResourceType, domain, method)
 	if getTokenErr != nil {
 		return getTokenErr
 	}
 
-	if setErr := resourceData.Set(recordTypeKey, "TXT"); setErr != nil {
+	if setErr := resourceData.Set(tokenKey, token); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(tokenMethodKey, method); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(tokenTypeKey, webResourceType); setErr != nil {
+		return setErr
+	}
+	alreadyVerified := false
+	if resourceData.Get(verifyExistingKey).(bool) {
+		_, getErr := getWebResource(provider.(configuredProvider), webResourceID(domain))
+		if getErr != nil && httpStatusCode(getErr) != http.StatusNotFound {
+			return getErr
+		}
+		alreadyVerified = getErr == nil
+	}
+	if setErr := resourceData.Set(alreadyVerifiedKey, alreadyVerified); setErr != nil {
+		return setErr
+	}
+	if webResourceType != siteType {
+		// the other types of web resources are not verified through DNS records
+		resourceData.SetId(domain)
+		return nil
+	}
+
+	record, recordErr := dnsRecordFromToken(domain, method, token)
+	if recordErr != nil {
+		return recordErr
+	}
+	if override := resourceData.Get(recordNameOverrideKey).(string); override != "" {
+		record.name = strings.TrimSuffix(override, ".")
+	}
+	if setErr := resourceData.Set(recordTypeKey, record.recordType); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(recordNameKey, record.name); setErr != nil {
 		return setErr
 	}
-	if setErr := resourceData.Set(recordNameKey, domain); setErr != nil {
+	if setErr := resourceData.Set(recordValueKey, record.value); setErr != nil {
 		return setErr
 	}
-	if setErr := resourceData.Set(recordValueKey, tokenResource.Token); setErr != nil {
+	if setErr := resourceData.Set(recordValueStringsKey, txtCharacterStrings(record.value)); setErr != nil {
 		return setErr
 	}
 	resourceData.SetId(domain)
@@ -225,21 +1112,109 @@ func readDnsSiteVerificationToken(resourceData *schema.ResourceData, provider in
 	return nil
 }
 
-func deleteDnsSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
-	service := provider.(configuredProvider).service
+// getToken fetches the token to use for verifying the domain with the given method.
+// The identifier is a domain for the DNS methods, and a site URL for the others.
+func getToken(provider configuredProvider, domain string, method string) (string, error) {
+	return getTokenOfType(provider, siteTypeOf(method), domain, method)
+}
 
+// getTokenOfType fetches the token to use for verifying the web resource of the given type with the given method.
+func getTokenOfType(provider configuredProvider, webResourceType string, identifier string, method string) (string, error) {
+	return getTokenWithin(provider, transientErrorTimeout, webResourceType, identifier, method)
+}
+
+// getTokenWithin fetches a token like getTokenOfType, retrying the transient errors, such as rate limiting,
+// and the permission errors while waiting for IAM, until the timeout.
+func getTokenWithin(provider configuredProvider, timeout time.Duration, webResourceType string, identifier string, method string) (string, error) {
+	if allowedErr := provider.checkMethodAllowed(method); allowedErr != nil {
+		return "", allowedErr
+	}
+
+	start := time.Now()
+	var token string
+	retryErr := provider.backoff.retry(timeout, func() *resource.RetryError {
+		ctx, cancel := provider.requestContext()
+		defer cancel()
+		tokenResource, getTokenErr := provider.service.WebResource.GetToken(&siteverification.SiteVerificationWebResourceGettokenRequest{
+			Site: &siteverification.SiteVerificationWebResourceGettokenRequestSite{
+				Identifier: identifier,
+				Type:       webResourceType,
+			},
+			VerificationMethod: method,
+		}).Context(ctx).Do()
+		if getTokenErr != nil {
+			if httpStatusCode(getTokenErr) == http.StatusForbidden && provider.forbiddenIsRetryable(start) {
+				log.Printf("[DEBUG] waiting for IAM permissions to propagate: %s", getTokenErr)
+				return resource.RetryableError(getTokenErr)
+			}
+			if transientStatusCodes[httpStatusCode(getTokenErr)] {
+				log.Printf("[DEBUG] retrying the token request of %s after a transient error: %s", identifier, getTokenErr)
+				return resource.RetryableError(getTokenErr)
+			}
+			return resource.NonRetryableError(getTokenErr)
+		}
+
+		token = tokenResource.Token
+		return nil
+	})
+	return token, retryErr
+}
+
+func deleteDnsSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
 	id := resourceData.Id()
-	if !strings.HasPrefix(resourceData.Id(), "dns://") {
-		// the provider 0.3.1 and earlier stored the domain as
-		// the id, which is incorrect.
-		id = fmt.Sprintf("dns://%s", id)
+	if writableErr := provider.(configuredProvider).checkWritable("unverify " + id); writableErr != nil {
+		return writableErr
 	}
 
-	return resource.Retry(resourceData.Timeout(schema.TimeoutDelete), func() *resource.RetryError {
-		err := service.WebResource.Delete(id).Do()
+	start := time.Now()
+	timeout := resourceData.Timeout(schema.TimeoutDelete)
+	method := resourceMethods(resourceData)[0]
+	if resourceData.Get(deleteWaitForRecordRemovalKey).(bool) && resourceSiteType(resourceData, method) == siteType {
+		domain := strings.TrimPrefix(id, "dns://")
+		record, recordErr := dnsRecordFromToken(domain, method, resourceData.Get(tokenKey).(string))
+		if recordErr != nil {
+			return recordErr
+		}
+		if waitErr := waitForRecordRemoval(context.Background(), resourceResolver(resourceData), provider.(configuredProvider).backoff, timeout, record); waitErr != nil {
+			return waitErr
+		}
+	}
+
+	if wwwErr := unverifyWww(resourceData, provider.(configuredProvider), timeout-time.Since(start), strings.TrimPrefix(id, "dns://")); wwwErr != nil {
+		return wwwErr
+	}
+	return deleteSiteVerification(provider.(configuredProvider), timeout-time.Since(start), id)
+}
+
+// webResourceID returns the id the API uses for a web resource, given a domain or the URL of another type of web resource.
+func webResourceID(identifier string) string {
+	if !strings.Contains(identifier, "://") {
+		return fmt.Sprintf("dns://%s", identifier)
+	}
+	return identifier
+}
+
+// deleteSiteVerification unverifies a web resource, retrying for as long as Google still sees the token.
+func deleteSiteVerification(provider configuredProvider, timeout time.Duration, id string) error {
+	if writableErr := provider.checkWritable("unverify " + id); writableErr != nil {
+		return writableErr
+	}
+
+	start := time.Now()
+	attempts := 0
+	retryErr := provider.backoff.retry(timeout, func() *resource.RetryError {
+		attempts++
+		ctx, cancel := provider.requestContext()
+		defer cancel()
+		err := provider.service.WebResource.Delete(id).Context(ctx).Do()
 		if err != nil {
-			if strings.Contains(err.Error(), tokenStillExists) {
-				log.Printf("retry: %s", err)
+			if httpStatusCode(err) == http.StatusNotFound {
+				// already unverified, which is what we want
+				log.Printf("[DEBUG] %s was already unverified", id)
+				return nil
+			}
+			if deleteErrorIsRetryable(provider, err) && !provider.retriesExhausted("delete", id, attempts) {
+				logAttempt("delete", id, attempts, start, timeout, err)
 				return resource.RetryableError(err)
 			} else {
 				return resource.NonRetryableError(err)
@@ -247,40 +1222,638 @@ func deleteDnsSiteVerification(resourceData *schema.ResourceData, provider inter
 		}
 		return nil
 	})
+	provider.metrics.record("delete", strings.TrimPrefix(id, "dns://"), time.Since(start), attempts, retryErr == nil)
+	if retryErr == nil {
+		provider.auditLog.record("delete", strings.TrimPrefix(id, "dns://"), "")
+	}
+	return retryErr
 }
 
 func readDnsSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
 	service := provider.(configuredProvider).service
 
-	_, getErr := service.WebResource.Get(resourceData.Id()).Do()
-	return getErr
+	webResource, getErr := getWebResource(provider.(configuredProvider), resourceData.Id())
+	if getErr != nil {
+		if httpStatusCode(getErr) == http.StatusNotFound {
+			// unverified out of band: Terraform will plan to verify it again
+			log.Printf("[WARN] %s is not verified anymore, removing it from the state", resourceData.Id())
+			resourceData.SetId("")
+			return nil
+		}
+		log.Printf("[DEBUG] reading site verification %s failed with HTTP status %d", resourceData.Id(), httpStatusCode(getErr))
+		return getErr
+	}
+
+	domain := resourceIdentifier(resourceData)
+	token := resourceData.Get(tokenKey).(string)
+
+	wwwVerified, idsErr := setVerifiedIDs(resourceData, provider.(configuredProvider), domain)
+	if idsErr != nil {
+		return idsErr
+	}
+	if !wwwVerified {
+		// Terraform will plan to verify both again, the domain being verified again as is
+		log.Printf("[WARN] the www. variant of %s is not verified anymore, removing it from the state", resourceData.Id())
+		resourceData.SetId("")
+		return nil
+	}
+
+	if setErr := resourceData.Set(methodKey, resourceMethod(resourceData)); setErr != nil {
+		return setErr
+	}
+	// the token is the one of the first method
+	method := resourceMethods(resourceData)[0]
+	webResourceType := resourceSiteType(resourceData, method)
+	if webResource.Site != nil && webResource.Site.Type != "" {
+		webResourceType = webResource.Site.Type
+	}
+	if setErr := resourceData.Set(siteTypeKey, webResourceType); setErr != nil {
+		return setErr
+	}
+
+	webResource, policyErr := enforceOwnerPolicy(resourceData, provider.(configuredProvider), webResource)
+	if policyErr != nil {
+		return policyErr
+	}
+
+	// after an update of the owners, the old value is the one from before it
+	oldOwners, currentOwners := resourceData.GetChange(ownersKey)
+	previousOwners := ownersList(oldOwners)
+	notifyOwnerChange(provider.(configuredProvider).ownerChangeWebhook, ownerChange{
+		Domain:         domain,
+		ID:             resourceData.Id(),
+		Owners:         webResource.Owners,
+		PreviousOwners: previousOwners,
+	})
+	owners := webResource.Owners
+	if sameOwners(owners, uniqueOwners(ownersList(currentOwners))) {
+		// Google does not keep the order of the owners: keeping the configured one avoids spurious diffs
+		owners = ownersList(currentOwners)
+	}
+	if setErr := resourceData.Set(ownersKey, owners); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(ownerCountKey, len(webResource.Owners)); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(primaryOwnerKey, primaryOwner(webResource.Owners, provider.(configuredProvider).managingIdentity)); setErr != nil {
+		return setErr
+	}
+	if setErr := setResult(resourceData, domain, method, webResource.Owners, nil); setErr != nil {
+		return setErr
+	}
+
+	if resourceData.Get(dnsCheckKey).(bool) && webResourceType == siteType {
+		if record, recordErr := dnsRecordFromToken(domain, method, token); recordErr != nil {
+			log.Printf("[WARN] could not check the DNS records of %s: %s", domain, recordErr)
+		} else {
+			warnIfRecordNotInDNS(context.Background(), resourceResolver(resourceData), record)
+		}
+	}
+
+	if !resourceData.Get(checkTokenStaleKey).(bool) {
+		if setErr := resourceData.Set(tokenStaleKey, false); setErr != nil {
+			return setErr
+		}
+	} else if currentToken, getTokenErr := getTokenOfType(provider.(configuredProvider), webResourceType, domain, method); getTokenErr != nil {
+		// a failure to compare the tokens should not prevent reading the verification
+		log.Printf("[WARN] could not check whether the token of %s is stale: %s", domain, getTokenErr)
+	} else if setErr := resourceData.Set(tokenStaleKey, currentToken != token); setErr != nil {
+		return setErr
+	}
+
+	if resourceData.Get(waitForSearchConsoleKey).(bool) {
+		ready, readyErr := searchConsoleReady(provider.(configuredProvider), domain)
+		if readyErr != nil {
+			log.Printf("[WARN] could not check %s in Search Console: %s", domain, readyErr)
+		}
+		if setErr := resourceData.Set(searchConsoleReadyKey, ready); setErr != nil {
+			return setErr
+		}
+	}
+
+	command := manualVerifyCommand(service.BasePath, resourceData.Get(manualVerifyCommandStyleKey).(string), webResourceType, domain, method)
+	if setErr := resourceData.Set(manualVerifyCommandKey, command); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(displayIDKey, strings.TrimPrefix(resourceData.Id(), "dns://")); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(webResourceIDKey, resourceData.Id()); setErr != nil {
+		return setErr
+	}
+	return resourceData.Set(lastStatusCodeKey, webResource.HTTPStatusCode)
+}
+
+// siteTypeOf returns the type of web resource the verification method applies to:
+// domains for the DNS methods, site URLs for the others.
+func siteTypeOf(method string) string {
+	for _, dnsMethod := range dnsVerificationMethods {
+		if method == dnsMethod {
+			return siteType
+		}
+	}
+	return urlSiteType
+}
+
+// insertErrorIsRetryable reports whether a verification that failed with err could succeed by trying again.
+// The client errors can't, except the token not being found yet, and the permission denied errors while waiting for IAM.
+func insertErrorIsRetryable(provider configuredProvider, start time.Time, err error) bool {
+	if isAlreadyOwned(err) || provider.interrupted() {
+		return false
+	}
+	switch httpStatusCode(err) {
+	case http.StatusBadRequest:
+		return strings.Contains(err.Error(), tokenNotFound)
+	case http.StatusUnauthorized, http.StatusNotFound:
+		return false
+	case http.StatusForbidden:
+		return provider.forbiddenIsRetryable(start)
+	default:
+		return true
+	}
+}
+
+// isAlreadyOwned reports whether a verification failed because the web resource is already verified by another account.
+func isAlreadyOwned(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Code == http.StatusConflict || strings.Contains(strings.ToLower(apiErr.Message), ownedByAnother) {
+		return true
+	}
+	for _, item := range apiErr.Errors {
+		for _, reason := range alreadyOwnedReasons {
+			if item.Reason == reason {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// alreadyOwnedErr explains what to do about a web resource which is verified by another account.
+func alreadyOwnedErr(identifier string, err error) error {
+	return fmt.Errorf("%s is already verified by another Google account, which it can't be taken from: ask one of its owners to add the provider's credentials as a delegated owner, then import it or manage its owners instead of verifying it again: %w", identifier, err)
+}
+
+// deleteErrorIsRetryable reports whether an unverification that failed with err could succeed by trying again.
+// Google refuses to unverify a web resource while it still sees its token with a 400, the only client error it returns
+// for the ids it handed out, so the code is matched rather than the message, which is localized.
+func deleteErrorIsRetryable(provider configuredProvider, err error) bool {
+	if statusCode := httpStatusCode(err); statusCode == http.StatusBadRequest || transientStatusCodes[statusCode] {
+		return true
+	}
+	for _, substring := range provider.deleteRetryOn {
+		if strings.Contains(err.Error(), substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// webResourceTypes are the types of web resources the API knows.
+var webResourceTypes = []string{siteType, urlSiteType, androidAppSiteType}
+
+// checkWebResourceType returns an error if the method can't verify the type of web resource:
+// domains are only verified with the DNS methods, which only verify domains.
+func checkWebResourceType(webResourceType string, method string) error {
+	if (webResourceType == siteType) != (siteTypeOf(method) == siteType) {
+		return fmt.Errorf("the %s method can't verify a web resource of type %s", method, webResourceType)
+	}
+	return nil
+}
+
+// validateIdentifier rejects the domains pasted as URLs, e.g. `https://example.com` or `example.com/`.
+// The URLs are accepted for the other types of web resources, whose identifiers checkIdentifier checks once the type is known.
+func validateIdentifier(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	if strings.Contains(v, "://") {
+		return nil, nil
+	}
+	// the domain properties of Search Console are domains too
+	if !bareDomainRegexp.MatchString(strings.TrimPrefix(strings.ToLower(v), scDomainPrefix)) {
+		return nil, []error{fmt.Errorf("%s: expected a bare domain such as example.com, without scheme, path nor trailing slash, got %q", k, v)}
+	}
+	return nil, nil
+}
+
+// checkIdentifier returns an error if the identifier is not in the format of the type of web resource:
+// a bare domain for domains, an http or https URL for sites, and an android-app:// URL for apps.
+func checkIdentifier(webResourceType string, identifier string) error {
+	switch webResourceType {
+	case siteType:
+		if !bareDomainRegexp.MatchString(strings.ToLower(identifier)) {
+			return fmt.Errorf("expected a bare domain such as example.com, without scheme, path nor trailing slash, got %q: use site_type = \"SITE\" to verify a site URL", identifier)
+		}
+	case urlSiteType:
+		if _, canonicalErr := canonicalSiteURL(identifier); canonicalErr != nil {
+			return canonicalErr
+		}
+	case androidAppSiteType:
+		if !strings.HasPrefix(identifier, "android-app://") {
+			return fmt.Errorf("expected an app URL such as android-app://com.example.app, got %q", identifier)
+		}
+	}
+	return nil
+}
+
+// resourceSiteType returns the type of web resource of the property_type, or the configured site type,
+// or the one the method applies to when none is.
+func resourceSiteType(resourceData *schema.ResourceData, method string) string {
+	if propertySiteType, _, _ := propertyIdentifier(resourceData.Get(propertyTypeKey).(string), resourceData.Get(domainKey).(string)); propertySiteType != "" {
+		return propertySiteType
+	}
+	if webResourceType, ok := resourceData.GetOk(siteTypeKey); ok {
+		return webResourceType.(string)
+	}
+	return siteTypeOf(method)
+}
+
+// resourceResolver returns the resolver to look up the DNS records of a googlesiteverification_dns resource with.
+func resourceResolver(resourceData *schema.ResourceData) *net.Resolver {
+	var servers []string
+	for _, server := range resourceData.Get(dnsCheckServersKey).([]interface{}) {
+		servers = append(servers, server.(string))
+	}
+	return newResolver(servers)
+}
+
+// resourceMethods returns the verification methods of a googlesiteverification_dns resource:
+// its methods, or its only method when they are not set.
+func resourceMethods(resourceData *schema.ResourceData) []string {
+	var methods []string
+	for _, method := range resourceData.Get(methodsKey).([]interface{}) {
+		methods = append(methods, method.(string))
+	}
+	if len(methods) == 0 {
+		return []string{resourceMethod(resourceData)}
+	}
+	return methods
+}
+
+// resourceMethod returns the verification method of a googlesiteverification_dns resource,
+// which is not in the state of the resources verified before it could be chosen.
+func resourceMethod(resourceData *schema.ResourceData) string {
+	if method := resourceData.Get(methodKey).(string); method != "" {
+		return method
+	}
+	return defaultVerificationMethod
+}
+
+// getWebResource reads a web resource, retrying the transient errors such as rate limiting.
+func getWebResource(provider configuredProvider, id string) (*siteverification.SiteVerificationWebResourceResource, error) {
+	var webResource *siteverification.SiteVerificationWebResourceResource
+	getErr := provider.backoff.retryableAPICall("reading "+id, func() error {
+		var err error
+		ctx, cancel := provider.requestContext()
+		defer cancel()
+		webResource, err = provider.service.WebResource.Get(id).Context(ctx).Do()
+		return err
+	})
+	return webResource, getErr
+}
+
+// listWebResources lists the web resources the credentials are an owner of, retrying the transient errors.
+func listWebResources(provider configuredProvider) (*siteverification.SiteVerificationWebResourceListResponse, error) {
+	var list *siteverification.SiteVerificationWebResourceListResponse
+	listErr := provider.backoff.retryableAPICall("listing the web resources", func() error {
+		var err error
+		ctx, cancel := provider.requestContext()
+		defer cancel()
+		list, err = provider.service.WebResource.List().Context(ctx).Do()
+		return err
+	})
+	return list, listErr
+}
+
+// httpStatusCode returns the HTTP status code carried by a googleapi error, or 0 if there is none.
+func httpStatusCode(err error) int {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+func customizeDnsSiteVerificationDiff(diff *schema.ResourceDiff, provider interface{}) error {
+	if diff.NewValueKnown(methodKey) && diff.NewValueKnown(methodsKey) && diff.NewValueKnown(siteTypeKey) && diff.NewValueKnown(propertyTypeKey) {
+		var methods []string
+		for _, method := range diff.Get(methodsKey).([]interface{}) {
+			methods = append(methods, method.(string))
+		}
+		if len(methods) == 0 {
+			methods = []string{diff.Get(methodKey).(string)}
+		}
+		webResourceType := diff.Get(siteTypeKey).(string)
+		identifier := diff.Get(domainKey).(string)
+		if diff.NewValueKnown(domainKey) {
+			propertySiteType, webResourceIdentifier, propertyErr := propertyIdentifier(diff.Get(propertyTypeKey).(string), identifier)
+			if propertyErr != nil {
+				return propertyErr
+			}
+			if propertySiteType != "" {
+				webResourceType = propertySiteType
+			}
+			identifier = webResourceIdentifier
+		}
+		if webResourceType == "" {
+			webResourceType = siteTypeOf(methods[0])
+		}
+		for _, method := range methods {
+			if typeErr := checkWebResourceType(webResourceType, method); typeErr != nil {
+				return typeErr
+			}
+		}
+		if diff.NewValueKnown(domainKey) {
+			if identifierErr := checkIdentifier(webResourceType, identifier); identifierErr != nil {
+				return identifierErr
+			}
+			if wwwErr := checkIncludeWww(diff.Get(includeWwwKey).(bool), webResourceType, identifier); wwwErr != nil {
+				return wwwErr
+			}
+		}
+	}
+	if diff.HasChange(ownersKey) && diff.NewValueKnown(ownersKey) {
+		if ownersErr := provider.(configuredProvider).checkOwners(ownersList(diff.Get(ownersKey))); ownersErr != nil {
+			return ownersErr
+		}
+	}
+	if diff.Id() != "" && (diff.HasChange(verificationTriggersKey) || diff.HasChange(tokenKey)) {
+		// the verification will be done again, which is what last_status_code will reflect
+		return diff.SetNewComputed(lastStatusCodeKey)
+	}
+	return nil
+}
+
+func updateDnsSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	// whether the www. variant is already verified again along with the domain
+	reverified := resourceData.HasChange(verificationTriggersKey) || resourceData.HasChange(tokenKey)
+	if reverified {
+		domain := resourceIdentifier(resourceData)
+
+		methods := resourceMethods(resourceData)
+		webResourceType := resourceSiteType(resourceData, methods[0])
+		start := time.Now()
+		timeout := resourceData.Timeout(schema.TimeoutUpdate)
+		var insertErr error
+		if resourceData.HasChange(tokenKey) && resourceData.Get(dnsCheckKey).(bool) && webResourceType == siteType {
+			insertErr = waitForTokenRecord(resourceData, provider.(configuredProvider), domain, methods[0], timeout)
+		}
+		id := resourceData.Id()
+		if insertErr == nil {
+			id, insertErr = insertMethods(provider.(configuredProvider), timeout-time.Since(start), webResourceType, domain, methods)
+		}
+		if insertErr == nil {
+			insertErr = verifyWww(resourceData, provider.(configuredProvider), timeout-time.Since(start), domain, methods)
+		}
+		if insertErr != nil {
+			// the domain is still verified with the previous token, which the state must keep
+			previousToken, _ := resourceData.GetChange(tokenKey)
+			if setErr := resourceData.Set(tokenKey, previousToken); setErr != nil {
+				return setErr
+			}
+			return insertErr
+		}
+		resourceData.SetId(id)
+
+		if _, tokenErr := setVerifiedToken(resourceData, provider.(configuredProvider), webResourceType, domain, methods[0]); tokenErr != nil {
+			return tokenErr
+		}
+	}
+
+	if resourceData.HasChange(includeWwwKey) && !(reverified && resourceData.Get(includeWwwKey).(bool)) {
+		domain := resourceIdentifier(resourceData)
+		timeout := resourceData.Timeout(schema.TimeoutUpdate)
+		var wwwErr error
+		if resourceData.Get(includeWwwKey).(bool) {
+			wwwErr = verifyWww(resourceData, provider.(configuredProvider), timeout, domain, resourceMethods(resourceData))
+		} else {
+			wwwErr = deleteSiteVerification(provider.(configuredProvider), timeout, webResourceID(wwwVariant(domain)))
+		}
+		if wwwErr != nil {
+			return wwwErr
+		}
+	}
+
+	if resourceData.HasChange(ownersKey) {
+		if ownersErr := updateOwners(provider.(configuredProvider), resourceData.Id(), uniqueOwners(ownersList(resourceData.Get(ownersKey)))); ownersErr != nil {
+			return ownersErr
+		}
+	}
+
+	// the other attributes only change how the provider behaves
+	return readDnsSiteVerification(resourceData, provider)
 }
 
 func createDnsSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
-	service := provider.(configuredProvider).service
-	domain := resourceData.Get(domainKey).(string)
+	domain := resourceIdentifier(resourceData)
+	if writableErr := provider.(configuredProvider).checkWritable("verify " + domain); writableErr != nil {
+		return writableErr
+	}
+
+	methods := resourceMethods(resourceData)
+	// the token is the one of the first method
+	method := methods[0]
+	webResourceType := resourceSiteType(resourceData, method)
+	for _, method := range methods {
+		if typeErr := checkWebResourceType(webResourceType, method); typeErr != nil {
+			return typeErr
+		}
+	}
 
-	return resource.Retry(resourceData.Timeout(schema.TimeoutCreate), func() *resource.RetryError {
-		r, insertErr := service.WebResource.Insert(verificationMethod, &siteverification.SiteVerificationWebResourceResource{
+	start := time.Now()
+	timeout := resourceData.Timeout(schema.TimeoutCreate)
+	if resourceData.Get(dnsCheckKey).(bool) && webResourceType == siteType {
+		if waitErr := waitForTokenRecord(resourceData, provider.(configuredProvider), domain, method, timeout); waitErr != nil {
+			return waitErr
+		}
+	}
+
+	// a failure to tell is no reason not to verify
+	newlyVerified := true
+	if _, getErr := getWebResource(provider.(configuredProvider), webResourceID(domain)); getErr == nil {
+		newlyVerified = false
+	} else if httpStatusCode(getErr) != http.StatusNotFound {
+		log.Printf("[WARN] could not check whether %s was already verified: %s", domain, getErr)
+	}
+
+	id, insertErr := insertMethods(provider.(configuredProvider), timeout-time.Since(start), webResourceType, domain, methods)
+	if insertErr != nil {
+		return insertErr
+	}
+	duration := time.Since(start)
+
+	resourceData.SetId(id)
+	if setErr := resourceData.Set(newlyVerifiedKey, newlyVerified); setErr != nil {
+		return setErr
+	}
+	// the domain is verified and in the state, so that a failure here is destroyed with it
+	if wwwErr := verifyWww(resourceData, provider.(configuredProvider), timeout-time.Since(start), domain, methods); wwwErr != nil {
+		return wwwErr
+	}
+
+	if _, tokenErr := setVerifiedToken(resourceData, provider.(configuredProvider), webResourceType, domain, method); tokenErr != nil {
+		return tokenErr
+	}
+
+	if owners, ok := resourceData.GetOk(ownersKey); ok {
+		if ownersErr := updateOwners(provider.(configuredProvider), id, uniqueOwners(ownersList(owners))); ownersErr != nil {
+			return ownersErr
+		}
+	}
+
+	// the owners are set by the read below, which keeps the duration
+	if setErr := setResult(resourceData, domain, method, nil, &duration); setErr != nil {
+		return setErr
+	}
+
+	if resourceData.Get(waitForSearchConsoleKey).(bool) {
+		waitForSearchConsole(provider.(configuredProvider), domain)
+	}
+
+	if provider.(configuredProvider).skipReadAfterCreate {
+		// the verification succeeded: what the read would add waits for the next refresh
+		if setErr := resourceData.Set(siteTypeKey, webResourceType); setErr != nil {
+			return setErr
+		}
+		if setErr := resourceData.Set(displayIDKey, strings.TrimPrefix(id, "dns://")); setErr != nil {
+			return setErr
+		}
+		ids := map[string]interface{}{domain: id}
+		if resourceData.Get(includeWwwKey).(bool) {
+			ids[wwwVariant(domain)] = webResourceID(wwwVariant(domain))
+		}
+		if setErr := resourceData.Set(verifiedIDsKey, ids); setErr != nil {
+			return setErr
+		}
+		return resourceData.Set(webResourceIDKey, id)
+	}
+	return readDnsSiteVerification(resourceData, provider)
+}
+
+// waitForTokenRecord waits for the DNS record of the token of a googlesiteverification_dns resource to be resolvable.
+func waitForTokenRecord(resourceData *schema.ResourceData, provider configuredProvider, domain string, method string, timeout time.Duration) error {
+	record, recordErr := dnsRecordFromToken(domain, method, resourceData.Get(tokenKey).(string))
+	if recordErr != nil {
+		return recordErr
+	}
+	return waitForRecord(context.Background(), resourceResolver(resourceData), provider.backoff, timeout, record)
+}
+
+// insertSiteVerification verifies a domain with the given method, retrying until the timeout,
+// and returns the id of the verified web resource.
+func insertSiteVerification(provider configuredProvider, timeout time.Duration, domain string, method string) (string, error) {
+	return insertWebResource(provider, timeout, siteTypeOf(method), domain, method)
+}
+
+// insertWebResource verifies a web resource of the given type with the given method, retrying until the timeout,
+// and returns the id of the verified web resource.
+func insertWebResource(provider configuredProvider, timeout time.Duration, webResourceType string, domain string, method string) (string, error) {
+	if writableErr := provider.checkWritable("verify " + domain); writableErr != nil {
+		return "", writableErr
+	}
+	if allowedErr := provider.checkMethodAllowed(method); allowedErr != nil {
+		return "", allowedErr
+	}
+	if preflightErr := provider.preflight.check(provider); preflightErr != nil {
+		return "", preflightErr
+	}
+
+	start := time.Now()
+	attempts := 0
+	var id string
+	retryErr := provider.backoff.retry(timeout, func() *resource.RetryError {
+		attempts++
+		ctx, cancel := provider.requestContext()
+		defer cancel()
+		r, insertErr := provider.service.WebResource.Insert(method, &siteverification.SiteVerificationWebResourceResource{
 			Site: &siteverification.SiteVerificationWebResourceResourceSite{
 				Identifier: domain,
-				Type:       siteType,
+				Type:       webResourceType,
 			},
-		}).Do()
+		}).Context(ctx).Do()
 		if insertErr != nil {
-			log.Printf("retrying failed site verification request, %s", insertErr)
+			if !insertErrorIsRetryable(provider, start, insertErr) {
+				if isAlreadyOwned(insertErr) {
+					return resource.NonRetryableError(alreadyOwnedErr(domain, insertErr))
+				}
+				return resource.NonRetryableError(insertErr)
+			}
+			if provider.retriesExhausted("create", domain, attempts) {
+				return resource.NonRetryableError(insertErr)
+			}
+			logAttempt("create", domain, attempts, start, timeout, insertErr)
 			return resource.RetryableError(insertErr)
 		}
 
-		id, err := url.QueryUnescape(r.Id)
+		var err error
+		id, err = url.QueryUnescape(r.Id)
 		if err != nil {
 			return resource.NonRetryableError(
 				fmt.Errorf(
 					"failed to urldecode id %s, %s", r.Id, err))
 		}
 
-		resourceData.SetId(id)
-
-		return resource.NonRetryableError(readDnsSiteVerification(resourceData, provider))
+		return nil
 	})
+	provider.metrics.record("create", domain, time.Since(start), attempts, retryErr == nil)
+	if retryErr == nil {
+		provider.auditLog.record("create", domain, method)
+	}
+	return id, retryErr
+}
+
+// setVerifiedToken fetches the token of a verified web resource and sets it as the verified_token of a googlesiteverification_dns resource.
+func setVerifiedToken(resourceData *schema.ResourceData, provider configuredProvider, webResourceType string, identifier string, method string) (string, error) {
+	token, getTokenErr := getTokenOfType(provider, webResourceType, identifier, method)
+	if getTokenErr != nil {
+		return "", getTokenErr
+	}
+	return token, resourceData.Set(verifiedTokenKey, token)
+}
+
+// insertMethods verifies the web resource with each of the methods in turn, and returns its id, which they all share.
+// The id of a domain is always the one of the domain itself, even when the API answers with another web resource.
+func insertMethods(provider configuredProvider, timeout time.Duration, webResourceType string, domain string, methods []string) (string, error) {
+	start := time.Now()
+	var id string
+	for _, method := range methods {
+		methodID, insertErr := insertWebResource(provider, timeout-time.Since(start), webResourceType, domain, method)
+		if insertErr != nil {
+			if webResourceType == siteType {
+				return "", withExpectedRecord(provider, domain, method, insertErr)
+			}
+			return "", insertErr
+		}
+		id = methodID
+	}
+
+	if expected := fmt.Sprintf("dns://%s", domain); webResourceType == siteType && !strings.EqualFold(id, expected) {
+		// e.g. the web resource of the apex for one of its subdomains, which would then share its id
+		log.Printf("[WARN] verifying %s returned the web resource %s, using %s as the id instead", domain, id, expected)
+		id = expected
+	}
+	return id, nil
+}
+
+// withExpectedRecord adds the DNS record Google expects to the error of a failed verification of a domain,
+// so that it can be compared with the record in place. The token is fetched again rather than taken from the
+// configuration, which may hold a stale one.
+func withExpectedRecord(provider configuredProvider, domain string, method string, insertErr error) error {
+	token, getTokenErr := getToken(provider, domain, method)
+	if getTokenErr != nil {
+		log.Printf("[WARN] could not get the token of %s to tell which DNS record is expected: %s", domain, getTokenErr)
+		return insertErr
+	}
+	record, recordErr := dnsRecordFromToken(domain, method, token)
+	if recordErr != nil {
+		log.Printf("[WARN] could not tell which DNS record is expected for %s: %s", domain, recordErr)
+		return insertErr
+	}
+	return fmt.Errorf("verifying %s failed, Google expects a DNS %s record named %s with the value %q: %w", domain, record.recordType, record.name, record.value, insertErr)
 }
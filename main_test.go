@@ -1,19 +1,36 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cloudflare/terraform-provider-cloudflare/cloudflare"
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/logging/v2"
+	"google.golang.org/api/option"
+	"google.golang.org/api/siteverification/v1"
+	"google.golang.org/api/webmasters/v3"
 )
 
 func TestAccDnsSiteVerification(t *testing.T) {
-	domain := fmt.Sprintf("%s-test-terraform-provider.hectorj.net", uuid.New())
+	domain := fmt.Sprintf("%s%s", uuid.New(), testDomainSuffix)
 
 	resource.Test(t, resource.TestCase{
 		Providers: map[string]terraform.ResourceProvider{
@@ -46,3 +63,1253 @@ resource "googlesiteverification_dns" "example" {
 		},
 	})
 }
+
+// newTestProvider returns a provider whose API calls are answered by handler.
+func newTestProvider(t *testing.T, handler http.HandlerFunc) configuredProvider {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	service, serviceErr := siteverification.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	if serviceErr != nil {
+		t.Fatal(serviceErr)
+	}
+
+	return configuredProvider{
+		service: service,
+		backoff: backoff{baseDelay: time.Millisecond, multiplier: 1},
+	}
+}
+
+// writeAPIError answers like the API does when a call fails.
+func writeAPIError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = fmt.Fprintf(w, `{"error": {"code": %d, "message": %q}}`, code, message)
+}
+
+func TestDeleteSiteVerificationAlreadyUnverified(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected %s request", r.Method)
+		}
+		writeAPIError(w, http.StatusNotFound, "Not Found")
+	})
+
+	if deleteErr := deleteSiteVerification(provider, time.Second, "dns://example.com"); deleteErr != nil {
+		t.Errorf("expected deleting an already unverified site to succeed, got %s", deleteErr)
+	}
+}
+
+func TestDeleteSiteVerificationForbidden(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusForbidden, "Forbidden")
+	})
+
+	if deleteErr := deleteSiteVerification(provider, time.Second, "dns://example.com"); httpStatusCode(deleteErr) != http.StatusForbidden {
+		t.Errorf("expected a 403 error, got %v", deleteErr)
+	}
+}
+
+func TestCheckMethodAllowed(t *testing.T) {
+	testCases := []struct {
+		name           string
+		allowedMethods []string
+		method         string
+		allowed        bool
+	}{
+		{name: "no policy", allowedMethods: nil, method: "DNS_CNAME", allowed: true},
+		{name: "allowed", allowedMethods: []string{"DNS_TXT"}, method: "DNS_TXT", allowed: true},
+		{name: "allowed among several", allowedMethods: []string{"DNS_CNAME", "DNS_TXT"}, method: "DNS_TXT", allowed: true},
+		{name: "disallowed", allowedMethods: []string{"DNS_TXT"}, method: "DNS_CNAME", allowed: false},
+		{name: "case sensitive", allowedMethods: []string{"DNS_TXT"}, method: "dns_txt", allowed: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider := configuredProvider{allowedMethods: testCase.allowedMethods}
+			allowedErr := provider.checkMethodAllowed(testCase.method)
+			if testCase.allowed && allowedErr != nil {
+				t.Errorf("expected %s to be allowed, got %s", testCase.method, allowedErr)
+			}
+			if !testCase.allowed && allowedErr == nil {
+				t.Errorf("expected %s not to be allowed", testCase.method)
+			}
+		})
+	}
+}
+
+func TestFindCredentialsAccessToken(t *testing.T) {
+	providerSchema := Provider().(*schema.Provider).Schema
+
+	resourceData := schema.TestResourceDataRaw(t, providerSchema, map[string]interface{}{accessTokenKey: "ya29.token"})
+	credentials, credentialsErr := findCredentials(resourceData, context.Background())
+	if credentialsErr != nil {
+		t.Fatal(credentialsErr)
+	}
+	if token, tokenErr := credentials.TokenSource.Token(); tokenErr != nil || token.AccessToken != "ya29.token" {
+		t.Errorf("expected the access token to be used as is, got %v (%v)", token, tokenErr)
+	}
+
+	resourceData = schema.TestResourceDataRaw(t, providerSchema, map[string]interface{}{accessTokenKey: "ya29.token", credentialsKey: "{}"})
+	if _, credentialsErr := findCredentials(resourceData, context.Background()); credentialsErr == nil {
+		t.Error("expected setting both credentials and access_token to fail")
+	}
+}
+
+func TestBase64CredentialsJSON(t *testing.T) {
+	credentialsJSON := `{"type": "service_account"}`
+
+	decoded, ok := base64CredentialsJSON(base64.StdEncoding.EncodeToString([]byte(credentialsJSON)) + "\n")
+	if !ok || string(decoded) != credentialsJSON {
+		t.Errorf("expected the base64-encoded credentials to be decoded, got %q", decoded)
+	}
+	for _, literal := range []string{credentialsJSON, "/path/to/key.json", "c2VjcmV0"} {
+		if _, ok := base64CredentialsJSON(literal); ok {
+			t.Errorf("expected %q not to be taken for base64-encoded credentials", literal)
+		}
+	}
+}
+
+func TestReadDnsSiteVerificationUnverified(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusNotFound, "Not Found")
+	})
+
+	resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"].Schema, map[string]interface{}{
+		domainKey: "example.com",
+		tokenKey:  "google-site-verification=abc",
+	})
+	resourceData.SetId("dns://example.com")
+
+	if readErr := readDnsSiteVerification(resourceData, provider); readErr != nil {
+		t.Fatalf("expected reading an unverified domain to succeed, got %s", readErr)
+	}
+	if resourceData.Id() != "" {
+		t.Errorf("expected the unverified domain to be removed from the state, got the id %q", resourceData.Id())
+	}
+
+	resourceData.SetId("dns://example.com")
+	if _, importErr := importSiteVerification(resourceData, provider); importErr == nil {
+		t.Error("expected importing an unverified domain to fail")
+	}
+}
+
+// verifiedHandler answers like the API does for example.com, verified and owned by owner@example.com.
+func verifiedHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasSuffix(r.URL.Path, "/token") {
+		_, _ = fmt.Fprint(w, `{"method": "DNS_TXT", "token": "google-site-verification=abc"}`)
+		return
+	}
+	_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "owners": ["owner@example.com"], "site": {"identifier": "example.com", "type": "INET_DOMAIN"}}`)
+}
+
+// readTestDnsSiteVerification reads the googlesiteverification_dns resource of example.com, configured with the given attributes too.
+func readTestDnsSiteVerification(t *testing.T, provider configuredProvider, config map[string]interface{}) *schema.ResourceData {
+	raw := map[string]interface{}{domainKey: "example.com", tokenKey: "google-site-verification=abc"}
+	for key, value := range config {
+		raw[key] = value
+	}
+	resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"].Schema, raw)
+	resourceData.SetId("dns://example.com")
+	if readErr := readDnsSiteVerification(resourceData, provider); readErr != nil {
+		t.Fatal(readErr)
+	}
+	return resourceData
+}
+
+func TestReadDnsSiteVerificationLastStatusCode(t *testing.T) {
+	resourceData := readTestDnsSiteVerification(t, newTestProvider(t, verifiedHandler), nil)
+	if statusCode := resourceData.Get(lastStatusCodeKey).(int); statusCode != http.StatusOK {
+		t.Errorf("expected the last status code to be 200, got %d", statusCode)
+	}
+}
+
+func TestReadDnsSiteVerificationDisplayID(t *testing.T) {
+	resourceData := readTestDnsSiteVerification(t, newTestProvider(t, verifiedHandler), nil)
+	if displayID := resourceData.Get(displayIDKey).(string); displayID != "example.com" {
+		t.Errorf("expected the display id example.com, without the dns:// prefix, got %q", displayID)
+	}
+}
+
+func TestReadDnsSiteVerificationWebResourceID(t *testing.T) {
+	resourceData := readTestDnsSiteVerification(t, newTestProvider(t, verifiedHandler), nil)
+	if webResourceID := resourceData.Get(webResourceIDKey).(string); webResourceID != "dns://example.com" {
+		t.Errorf("expected the web resource id dns://example.com, got %q", webResourceID)
+	}
+}
+
+func TestReadDnsSiteVerificationTokenStale(t *testing.T) {
+	for _, checkTokenStale := range []bool{false, true} {
+		t.Run(fmt.Sprintf("check token stale %t", checkTokenStale), func(t *testing.T) {
+			tokenCalls := 0
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if strings.HasSuffix(r.URL.Path, "/token") {
+					tokenCalls++
+					_, _ = fmt.Fprint(w, `{"method": "DNS_TXT", "token": "google-site-verification=new"}`)
+					return
+				}
+				_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "site": {"identifier": "example.com", "type": "INET_DOMAIN"}}`)
+			})
+
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"].Schema, map[string]interface{}{
+				domainKey:          "example.com",
+				tokenKey:           "google-site-verification=abc",
+				checkTokenStaleKey: checkTokenStale,
+			})
+			resourceData.SetId("dns://example.com")
+
+			if readErr := readDnsSiteVerification(resourceData, provider); readErr != nil {
+				t.Fatal(readErr)
+			}
+			if stale := resourceData.Get(tokenStaleKey).(bool); stale != checkTokenStale {
+				t.Errorf("expected token_stale to be %t, got %t", checkTokenStale, stale)
+			}
+			if expectedCalls := map[bool]int{false: 0, true: 1}[checkTokenStale]; tokenCalls != expectedCalls {
+				t.Errorf("expected %d token requests, got %d", expectedCalls, tokenCalls)
+			}
+		})
+	}
+}
+
+func TestInsertErrorIsRetryable(t *testing.T) {
+	testCases := []struct {
+		code     int
+		message  string
+		expected bool
+	}{
+		{code: http.StatusBadRequest, message: "The necessary verification token could not be found on your site.", expected: true},
+		{code: http.StatusBadRequest, message: "Invalid value for site identifier.", expected: false},
+		{code: http.StatusUnauthorized, message: "Invalid Credentials", expected: false},
+		{code: http.StatusForbidden, message: "Forbidden", expected: false},
+		{code: http.StatusNotFound, message: "Not Found", expected: false},
+		{code: http.StatusPreconditionFailed, message: "Precondition Failed", expected: true},
+		{code: http.StatusServiceUnavailable, message: "Backend Error", expected: true},
+		{code: http.StatusConflict, message: "Conflict", expected: false},
+		{code: http.StatusForbidden, message: "The site is owned by another account.", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d %s", testCase.code, testCase.message), func(t *testing.T) {
+			err := &googleapi.Error{Code: testCase.code, Message: testCase.message}
+			if actual := insertErrorIsRetryable(configuredProvider{}, time.Now(), err); actual != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCheckWebResourceType(t *testing.T) {
+	testCases := []struct {
+		webResourceType string
+		method          string
+		valid           bool
+	}{
+		{webResourceType: "INET_DOMAIN", method: "DNS_TXT", valid: true},
+		{webResourceType: "INET_DOMAIN", method: "DNS_CNAME", valid: true},
+		{webResourceType: "INET_DOMAIN", method: "META", valid: false},
+		{webResourceType: "SITE", method: "DNS_TXT", valid: false},
+		{webResourceType: "SITE", method: "FILE", valid: true},
+		{webResourceType: "ANDROID_APP", method: "DNS_CNAME", valid: false},
+		{webResourceType: "ANDROID_APP", method: "ANALYTICS", valid: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.webResourceType+" "+testCase.method, func(t *testing.T) {
+			if err := checkWebResourceType(testCase.webResourceType, testCase.method); (err == nil) != testCase.valid {
+				t.Errorf("expected valid=%t, got %v", testCase.valid, err)
+			}
+		})
+	}
+}
+
+func TestCheckIdentifier(t *testing.T) {
+	testCases := []struct {
+		webResourceType string
+		identifier      string
+		valid           bool
+	}{
+		{webResourceType: "INET_DOMAIN", identifier: "example.com", valid: true},
+		{webResourceType: "INET_DOMAIN", identifier: "Sub.Example.com", valid: true},
+		{webResourceType: "INET_DOMAIN", identifier: "https://example.com", valid: false},
+		{webResourceType: "INET_DOMAIN", identifier: "example.com/", valid: false},
+		{webResourceType: "INET_DOMAIN", identifier: "example.com/path", valid: false},
+		{webResourceType: "SITE", identifier: "https://www.example.com/", valid: true},
+		{webResourceType: "SITE", identifier: "example.com", valid: false},
+		{webResourceType: "ANDROID_APP", identifier: "android-app://com.example.app", valid: true},
+		{webResourceType: "ANDROID_APP", identifier: "com.example.app", valid: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.webResourceType+" "+testCase.identifier, func(t *testing.T) {
+			if err := checkIdentifier(testCase.webResourceType, testCase.identifier); (err == nil) != testCase.valid {
+				t.Errorf("expected valid=%t, got %v", testCase.valid, err)
+			}
+		})
+	}
+}
+
+func TestWithExpectedRecord(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"method": "DNS_TXT", "token": "google-site-verification=abc"}`)
+	})
+	insertErr := &googleapi.Error{Code: http.StatusBadRequest, Message: "The necessary verification token could not be found on your site."}
+
+	err := withExpectedRecord(provider, "example.com", "DNS_TXT", insertErr)
+	if !errors.Is(err, insertErr) {
+		t.Errorf("expected the error of the verification to be wrapped, got %v", err)
+	}
+	expected := `verifying example.com failed, Google expects a DNS TXT record named example.com with the value "google-site-verification=abc": `
+	if !strings.HasPrefix(err.Error(), expected) {
+		t.Errorf("expected the error to start with %q, got %q", expected, err)
+	}
+}
+
+func TestInsertMethods(t *testing.T) {
+	var methods []string
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.URL.Query().Get("verificationMethod"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "site": {"identifier": "example.com", "type": "INET_DOMAIN"}}`)
+	})
+
+	id, insertErr := insertMethods(provider, time.Second, "INET_DOMAIN", "example.com", []string{"DNS_TXT", "DNS_CNAME"})
+	if insertErr != nil {
+		t.Fatal(insertErr)
+	}
+	if id != "dns://example.com" {
+		t.Errorf("expected the id dns://example.com, got %s", id)
+	}
+	if strings.Join(methods, ",") != "DNS_TXT,DNS_CNAME" {
+		t.Errorf("expected a verification with each method in turn, got %v", methods)
+	}
+}
+
+func TestImportSiteVerificationWithoutPrefix(t *testing.T) {
+	testCases := []struct {
+		importID       string
+		expectedID     string
+		expectedDomain string
+		expectedType   string
+		expectedMethod string
+	}{
+		{importID: "example.com", expectedID: "dns://example.com", expectedDomain: "example.com", expectedType: "INET_DOMAIN", expectedMethod: "DNS_TXT"},
+		{importID: "dns://example.com", expectedID: "dns://example.com", expectedDomain: "example.com", expectedType: "INET_DOMAIN", expectedMethod: "DNS_TXT"},
+		{importID: "sc-domain:example.com", expectedID: "dns://example.com", expectedDomain: "sc-domain:example.com", expectedType: "INET_DOMAIN", expectedMethod: "DNS_TXT"},
+		{importID: "https://Example.com:443", expectedID: "https://example.com/", expectedDomain: "https://Example.com:443", expectedType: "SITE", expectedMethod: "META"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.importID, func(t *testing.T) {
+			var tokenRequest siteverification.SiteVerificationWebResourceGettokenRequest
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.Method == http.MethodGet {
+					if r.URL.Path != "/webResource/"+testCase.expectedID {
+						t.Errorf("expected the canonical id %s to be read, got %s", testCase.expectedID, r.URL.Path)
+					}
+					identifier := strings.TrimPrefix(testCase.expectedID, "dns://")
+					_, _ = fmt.Fprintf(w, `{"id": %q, "owners": ["owner@example.com"], "site": {"identifier": %q, "type": %q}}`, testCase.expectedID, identifier, testCase.expectedType)
+					return
+				}
+				_ = json.NewDecoder(r.Body).Decode(&tokenRequest)
+				_, _ = fmt.Fprintf(w, `{"method": %q, "token": "google-site-verification=abc"}`, tokenRequest.VerificationMethod)
+			})
+
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"].Schema, map[string]interface{}{})
+			resourceData.SetId(testCase.importID)
+
+			imported, importErr := importSiteVerification(resourceData, provider)
+			if importErr != nil {
+				t.Fatal(importErr)
+			}
+			if id := imported[0].Id(); id != testCase.expectedID {
+				t.Errorf("expected the id %s, got %s", testCase.expectedID, id)
+			}
+			if domain := imported[0].Get(domainKey).(string); domain != testCase.expectedDomain {
+				t.Errorf("expected the domain %s, got %s", testCase.expectedDomain, domain)
+			}
+			if webResourceType := imported[0].Get(siteTypeKey).(string); webResourceType != testCase.expectedType {
+				t.Errorf("expected the site type %s, got %s", testCase.expectedType, webResourceType)
+			}
+			if method := imported[0].Get(methodKey).(string); method != testCase.expectedMethod || tokenRequest.VerificationMethod != testCase.expectedMethod {
+				t.Errorf("expected the method %s, got %s with a token requested for %s", testCase.expectedMethod, method, tokenRequest.VerificationMethod)
+			}
+			if tokenRequest.Site == nil || tokenRequest.Site.Type != testCase.expectedType {
+				t.Errorf("expected a token of a %s, got %+v", testCase.expectedType, tokenRequest.Site)
+			}
+			if token := imported[0].Get(verifiedTokenKey).(string); token != "google-site-verification=abc" {
+				t.Errorf("expected the verified token google-site-verification=abc, got %s", token)
+			}
+		})
+	}
+}
+
+func TestDeleteErrorIsRetryable(t *testing.T) {
+	provider := configuredProvider{deleteRetryOn: []string{"Conflict with a concurrent change"}}
+	testCases := []struct {
+		code     int
+		message  string
+		expected bool
+	}{
+		{code: http.StatusBadRequest, message: "You cannot unverify your ownership of this site until your verification token has been removed.", expected: true},
+		{code: http.StatusBadRequest, message: "Vous ne pouvez pas annuler la validation de ce site tant que le jeton de validation n'a pas été supprimé.", expected: true},
+		{code: http.StatusForbidden, message: "Forbidden", expected: false},
+		{code: http.StatusTooManyRequests, message: "Rate Limit Exceeded", expected: true},
+		{code: http.StatusConflict, message: "Conflict with a concurrent change", expected: true},
+		{code: http.StatusConflict, message: "Conflict", expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d %s", testCase.code, testCase.message), func(t *testing.T) {
+			err := &googleapi.Error{Code: testCase.code, Message: testCase.message}
+			if actual := deleteErrorIsRetryable(provider, err); actual != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateAPIAccessMissingScope(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"error": {"code": 403, "message": "Request had insufficient authentication scopes.", "errors": [{"reason": "insufficientPermissions"}]}}`)
+	})
+
+	expected := "the provided credentials are missing the https://www.googleapis.com/auth/siteverification OAuth scope"
+	if validateErr := validateAPIAccess(context.Background(), provider.service); validateErr == nil || validateErr.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, validateErr)
+	}
+}
+
+func TestInsertMethodsSubdomain(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		// the API answers with the web resource of the already verified apex
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "site": {"identifier": "example.com", "type": "INET_DOMAIN"}}`)
+	})
+
+	apexID, apexErr := insertMethods(provider, time.Second, "INET_DOMAIN", "example.com", []string{"DNS_TXT"})
+	if apexErr != nil {
+		t.Fatal(apexErr)
+	}
+	subdomainID, subdomainErr := insertMethods(provider, time.Second, "INET_DOMAIN", "app.example.com", []string{"DNS_TXT"})
+	if subdomainErr != nil {
+		t.Fatal(subdomainErr)
+	}
+	if apexID != "dns://example.com" || subdomainID != "dns://app.example.com" {
+		t.Errorf("expected the apex and the subdomain to have their own ids, got %s and %s", apexID, subdomainID)
+	}
+}
+
+func TestUpgradeDnsSiteVerificationV0(t *testing.T) {
+	testCases := map[string]string{
+		"example.com":              "dns://example.com",
+		"dns://example.com":        "dns://example.com",
+		"https://www.example.com/": "https://www.example.com/",
+	}
+
+	for id, expected := range testCases {
+		t.Run(id, func(t *testing.T) {
+			upgraded, upgradeErr := upgradeDnsSiteVerificationV0(map[string]interface{}{idKey: id, domainKey: "example.com"}, nil)
+			if upgradeErr != nil {
+				t.Fatal(upgradeErr)
+			}
+			if upgraded[idKey] != expected {
+				t.Errorf("expected the id %s, got %v", expected, upgraded[idKey])
+			}
+		})
+	}
+}
+
+func TestUpdateTokenKeepsPreviousVerificationOnFailure(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected only a verification, got a %s request", r.Method)
+		}
+		writeAPIError(w, http.StatusUnauthorized, "Unauthorized")
+	})
+
+	dnsResource := Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"]
+	state := &terraform.InstanceState{
+		ID: "dns://example.com",
+		Attributes: map[string]string{
+			"id":      "dns://example.com",
+			domainKey: "example.com",
+			tokenKey:  "google-site-verification=old",
+			methodKey: "DNS_TXT",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		domainKey: "example.com",
+		tokenKey:  "google-site-verification=new",
+	})
+	diff, diffErr := dnsResource.Diff(state, config, provider)
+	if diffErr != nil {
+		t.Fatal(diffErr)
+	}
+	if diff.RequiresNew() {
+		t.Fatal("expected a change of token to be an update")
+	}
+
+	newState, applyErr := dnsResource.Apply(state, diff, provider)
+	if applyErr == nil {
+		t.Fatal("expected the failed verification to be reported")
+	}
+	if token := newState.Attributes[tokenKey]; token != "google-site-verification=old" {
+		t.Errorf("expected the state to keep the previous token, got %q", token)
+	}
+	if newState.ID != "dns://example.com" {
+		t.Errorf("expected the verification to stay in the state, got the id %q", newState.ID)
+	}
+}
+
+func TestUpdateVerificationTriggersReverifies(t *testing.T) {
+	var inserts int
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			t.Error("expected the domain to be verified again without being unverified first")
+		case http.MethodPost:
+			if !strings.HasSuffix(r.URL.Path, "/token") {
+				inserts++
+			}
+		}
+		verifiedHandler(w, r)
+	})
+
+	dnsResource := Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"]
+	state := &terraform.InstanceState{
+		ID: "dns://example.com",
+		Attributes: map[string]string{
+			"id":                                "dns://example.com",
+			domainKey:                           "example.com",
+			tokenKey:                            "google-site-verification=abc",
+			methodKey:                           "DNS_TXT",
+			dnsCheckKey:                         "false",
+			verificationTriggersKey + ".%":      "1",
+			verificationTriggersKey + ".record": "1",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		domainKey:               "example.com",
+		tokenKey:                "google-site-verification=abc",
+		dnsCheckKey:             false,
+		verificationTriggersKey: map[string]interface{}{"record": "2"},
+	})
+	diff, diffErr := dnsResource.Diff(state, config, provider)
+	if diffErr != nil {
+		t.Fatal(diffErr)
+	}
+	if diff.RequiresNew() {
+		t.Fatal("expected a change of verification_triggers to be an update")
+	}
+	if _, applyErr := dnsResource.Apply(state, diff, provider); applyErr != nil {
+		t.Fatal(applyErr)
+	}
+	if inserts != 1 {
+		t.Errorf("expected the domain to be verified again once, got %d verifications", inserts)
+	}
+}
+
+func TestReadDnsSiteVerificationTokenRecordNameOverride(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"method": "DNS_TXT", "token": "google-site-verification=abc"}`)
+	})
+	dataSource := Provider().(*schema.Provider).DataSourcesMap["googlesiteverification_dns_token"]
+
+	resourceData := schema.TestResourceDataRaw(t, dataSource.Schema, map[string]interface{}{
+		domainKey:             "example.com",
+		recordNameOverrideKey: "example.com.internal-dns.example.net.",
+	})
+	if readErr := readDnsSiteVerificationToken(resourceData, provider); readErr != nil {
+		t.Fatal(readErr)
+	}
+	if name := resourceData.Get(recordNameKey).(string); name != "example.com.internal-dns.example.net" {
+		t.Errorf("expected the record to live at the override, got %q", name)
+	}
+	if value := resourceData.Get(recordValueKey).(string); value != "google-site-verification=abc" {
+		t.Errorf("expected the record value to be the token, got %q", value)
+	}
+
+	resourceData = schema.TestResourceDataRaw(t, dataSource.Schema, map[string]interface{}{
+		domainKey:             "example.com",
+		methodKey:             "DNS_CNAME",
+		recordNameOverrideKey: "elsewhere.example.net",
+	})
+	if readErr := readDnsSiteVerificationToken(resourceData, provider); readErr == nil {
+		t.Error("expected the override to be refused for the DNS_CNAME method")
+	}
+}
+
+func TestReadDnsSiteVerificationTokenAlreadyVerified(t *testing.T) {
+	testCases := []struct {
+		name           string
+		verifyExisting bool
+		getStatus      int
+		expected       bool
+		fails          bool
+	}{
+		{name: "not checked", verifyExisting: false, expected: false},
+		{name: "verified", verifyExisting: true, getStatus: http.StatusOK, expected: true},
+		{name: "not verified", verifyExisting: true, getStatus: http.StatusNotFound, expected: false},
+		{name: "forbidden", verifyExisting: true, getStatus: http.StatusForbidden, fails: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					if !testCase.verifyExisting {
+						t.Error("expected the verification not to be checked without verify_existing")
+					}
+					if testCase.getStatus != http.StatusOK {
+						writeAPIError(w, testCase.getStatus, http.StatusText(testCase.getStatus))
+						return
+					}
+				}
+				verifiedHandler(w, r)
+			})
+			dataSource := Provider().(*schema.Provider).DataSourcesMap["googlesiteverification_dns_token"]
+
+			resourceData := schema.TestResourceDataRaw(t, dataSource.Schema, map[string]interface{}{
+				domainKey:         "example.com",
+				verifyExistingKey: testCase.verifyExisting,
+			})
+			readErr := readDnsSiteVerificationToken(resourceData, provider)
+			if testCase.fails {
+				if readErr == nil {
+					t.Error("expected the check of the verification to fail")
+				}
+				return
+			}
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if alreadyVerified := resourceData.Get(alreadyVerifiedKey).(bool); alreadyVerified != testCase.expected {
+				t.Errorf("expected already_verified to be %t, got %t", testCase.expected, alreadyVerified)
+			}
+		})
+	}
+}
+
+func TestCreateSiteVerifiesCanonicalURL(t *testing.T) {
+	var identifiers []string
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/token"):
+			var request siteverification.SiteVerificationWebResourceGettokenRequest
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			identifiers = append(identifiers, request.Site.Identifier)
+			_, _ = fmt.Fprint(w, `{"method": "META", "token": "<meta name=\"google-site-verification\" content=\"abc\">"}`)
+		case r.Method == http.MethodPost:
+			var webResource siteverification.SiteVerificationWebResourceResource
+			_ = json.NewDecoder(r.Body).Decode(&webResource)
+			identifiers = append(identifiers, webResource.Site.Identifier)
+			_, _ = fmt.Fprintf(w, `{"id": %q, "site": {"identifier": %q, "type": "SITE"}}`, webResource.Site.Identifier, webResource.Site.Identifier)
+		default:
+			writeAPIError(w, http.StatusNotFound, "Not Found")
+		}
+	})
+	provider.skipReadAfterCreate = true
+
+	resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"].Schema, map[string]interface{}{
+		domainKey:   "https://Example.com:443",
+		tokenKey:    "abc",
+		methodKey:   "META",
+		siteTypeKey: "SITE",
+	})
+	if createErr := createDnsSiteVerification(resourceData, provider); createErr != nil {
+		t.Fatal(createErr)
+	}
+	for _, identifier := range identifiers {
+		if identifier != "https://example.com/" {
+			t.Errorf("expected the site to be verified as https://example.com/, got %q", identifier)
+		}
+	}
+	if len(identifiers) == 0 {
+		t.Error("expected the site to be verified")
+	}
+}
+
+func TestInsertAlreadyOwned(t *testing.T) {
+	calls := 0
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"error": {"code": 403, "message": "Forbidden", "errors": [{"reason": "alreadyExists", "message": "Forbidden"}]}}`)
+	})
+	provider.waitForIAM = true
+
+	_, insertErr := insertSiteVerification(provider, time.Minute, "example.com", "DNS_TXT")
+	if insertErr == nil || !strings.Contains(insertErr.Error(), "delegated owner") {
+		t.Errorf("expected the domain to be reported as owned by another account, got %v", insertErr)
+	}
+	if calls != 1 {
+		t.Errorf("expected the verification not to be retried, got %d calls", calls)
+	}
+}
+
+func TestCreateNewlyVerified(t *testing.T) {
+	for _, alreadyVerified := range []bool{false, true} {
+		t.Run(fmt.Sprintf("already verified %t", alreadyVerified), func(t *testing.T) {
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.Method == http.MethodGet && !alreadyVerified:
+					writeAPIError(w, http.StatusNotFound, "Not Found")
+				case strings.HasSuffix(r.URL.Path, "/token"):
+					_, _ = fmt.Fprint(w, `{"method": "DNS_TXT", "token": "google-site-verification=abc"}`)
+				default:
+					_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "site": {"identifier": "example.com", "type": "INET_DOMAIN"}}`)
+				}
+			})
+			provider.skipReadAfterCreate = true
+
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"].Schema, map[string]interface{}{
+				domainKey:   "example.com",
+				tokenKey:    "google-site-verification=abc",
+				dnsCheckKey: false,
+			})
+			if createErr := createDnsSiteVerification(resourceData, provider); createErr != nil {
+				t.Fatal(createErr)
+			}
+			if newlyVerified := resourceData.Get(newlyVerifiedKey).(bool); newlyVerified == alreadyVerified {
+				t.Errorf("expected newly_verified to be %t, got %t", !alreadyVerified, newlyVerified)
+			}
+		})
+	}
+}
+
+func TestCreateSkipReadAfterCreate(t *testing.T) {
+	for _, skip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("skip %t", skip), func(t *testing.T) {
+			inserted := false
+			readsAfterInsert := 0
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPost && !strings.HasSuffix(r.URL.Path, "/token"):
+					inserted = true
+				case r.Method == http.MethodGet && !inserted:
+					writeAPIError(w, http.StatusNotFound, "Not Found")
+					return
+				case r.Method == http.MethodGet:
+					readsAfterInsert++
+				}
+				verifiedHandler(w, r)
+			})
+			provider.skipReadAfterCreate = skip
+
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"].Schema, map[string]interface{}{
+				domainKey:   "example.com",
+				tokenKey:    "google-site-verification=abc",
+				dnsCheckKey: false,
+			})
+			if createErr := createDnsSiteVerification(resourceData, provider); createErr != nil {
+				t.Fatal(createErr)
+			}
+			if skip && readsAfterInsert != 0 {
+				t.Errorf("expected no read after the verification, got %d", readsAfterInsert)
+			}
+			if !skip && readsAfterInsert == 0 {
+				t.Error("expected the verification to be read after it is done")
+			}
+			if displayID := resourceData.Get(displayIDKey).(string); displayID != "example.com" {
+				t.Errorf("expected the display id example.com, got %q", displayID)
+			}
+			if webResourceID := resourceData.Get(webResourceIDKey).(string); webResourceID != "dns://example.com" {
+				t.Errorf("expected the web resource id dns://example.com, got %q", webResourceID)
+			}
+		})
+	}
+}
+
+func TestReadDnsSiteVerificationTokenRetries(t *testing.T) {
+	testCases := []struct {
+		code          int
+		expectedCalls int
+		succeeds      bool
+	}{
+		{code: http.StatusServiceUnavailable, expectedCalls: 2, succeeds: true},
+		{code: http.StatusTooManyRequests, expectedCalls: 2, succeeds: true},
+		{code: http.StatusBadRequest, expectedCalls: 1, succeeds: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprint(testCase.code), func(t *testing.T) {
+			calls := 0
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					writeAPIError(w, testCase.code, http.StatusText(testCase.code))
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprint(w, `{"method": "DNS_TXT", "token": "google-site-verification=abc"}`)
+			})
+
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).DataSourcesMap["googlesiteverification_dns_token"].Schema, map[string]interface{}{
+				domainKey: "example.com",
+			})
+			readErr := readDnsSiteVerificationToken(resourceData, provider)
+			if (readErr == nil) != testCase.succeeds {
+				t.Errorf("expected success=%t, got %v", testCase.succeeds, readErr)
+			}
+			if calls != testCase.expectedCalls {
+				t.Errorf("expected %d calls, got %d", testCase.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	var mutatingCalls []string
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			mutatingCalls = append(mutatingCalls, r.Method+" "+r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "site": {"identifier": "example.com", "type": "INET_DOMAIN"}, "owners": ["owner@example.com"]}`)
+	})
+	provider.readOnly = true
+
+	if _, insertErr := insertSiteVerification(provider, time.Minute, "example.com", "DNS_TXT"); insertErr == nil || !strings.Contains(insertErr.Error(), readOnlyKey) {
+		t.Errorf("expected the verification to be refused, got %v", insertErr)
+	}
+	if deleteErr := deleteSiteVerification(provider, time.Minute, "dns://example.com"); deleteErr == nil || !strings.Contains(deleteErr.Error(), readOnlyKey) {
+		t.Errorf("expected the unverification to be refused, got %v", deleteErr)
+	}
+	if ownersErr := updateOwners(provider, "dns://example.com", []string{"other@example.com"}); ownersErr == nil || !strings.Contains(ownersErr.Error(), readOnlyKey) {
+		t.Errorf("expected the owners update to be refused, got %v", ownersErr)
+	}
+	if len(mutatingCalls) > 0 {
+		t.Errorf("expected no mutating call, got %v", mutatingCalls)
+	}
+
+	if _, getErr := getWebResource(provider, "dns://example.com"); getErr != nil {
+		t.Errorf("expected the reads to keep working, got %v", getErr)
+	}
+}
+
+func TestMaxRetries(t *testing.T) {
+	testCases := []struct {
+		maxRetries    int
+		expectedCalls int
+	}{
+		{maxRetries: 1, expectedCalls: 2},
+		{maxRetries: 3, expectedCalls: 4},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprint(testCase.maxRetries), func(t *testing.T) {
+			calls := map[string]int{}
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				calls[r.Method]++
+				writeAPIError(w, http.StatusServiceUnavailable, "Service Unavailable")
+			})
+			provider.maxRetries = testCase.maxRetries
+
+			if _, insertErr := insertSiteVerification(provider, time.Minute, "example.com", "DNS_TXT"); httpStatusCode(insertErr) != http.StatusServiceUnavailable {
+				t.Errorf("expected the last error of the verification, got %v", insertErr)
+			}
+			if deleteErr := deleteSiteVerification(provider, time.Minute, "dns://example.com"); httpStatusCode(deleteErr) != http.StatusServiceUnavailable {
+				t.Errorf("expected the last error of the unverification, got %v", deleteErr)
+			}
+			if calls[http.MethodPost] != testCase.expectedCalls || calls[http.MethodDelete] != testCase.expectedCalls {
+				t.Errorf("expected %d attempts of each, got %v", testCase.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestReadDnsSiteVerificationTokenEchoesRequest(t *testing.T) {
+	testCases := []struct {
+		config         map[string]interface{}
+		expectedMethod string
+		expectedType   string
+	}{
+		{
+			config:         map[string]interface{}{domainKey: "example.com"},
+			expectedMethod: "DNS_TXT",
+			expectedType:   "INET_DOMAIN",
+		},
+		{
+			config:         map[string]interface{}{domainKey: "sc-domain:example.com", methodKey: "DNS_CNAME"},
+			expectedMethod: "DNS_CNAME",
+			expectedType:   "INET_DOMAIN",
+		},
+		{
+			config:         map[string]interface{}{domainKey: "https://example.com/", methodKey: "META"},
+			expectedMethod: "META",
+			expectedType:   "SITE",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprint(testCase.config[domainKey]), func(t *testing.T) {
+			var request siteverification.SiteVerificationWebResourceGettokenRequest
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&request)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprint(w, `{"token": "abc123.example.com CNAME gv-xyz.dv.googlehosted.com"}`)
+			})
+
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).DataSourcesMap["googlesiteverification_dns_token"].Schema, testCase.config)
+			if readErr := readDnsSiteVerificationToken(resourceData, provider); readErr != nil {
+				t.Fatal(readErr)
+			}
+			method, webResourceType := resourceData.Get(tokenMethodKey).(string), resourceData.Get(tokenTypeKey).(string)
+			if method != testCase.expectedMethod || webResourceType != testCase.expectedType {
+				t.Errorf("expected %s %s, got %s %s", testCase.expectedMethod, testCase.expectedType, method, webResourceType)
+			}
+			if method != request.VerificationMethod || webResourceType != request.Site.Type {
+				t.Errorf("expected the attributes to echo the request, sent %s %s", request.VerificationMethod, request.Site.Type)
+			}
+		})
+	}
+}
+
+func TestListWebResourcesRetriesTransientErrors(t *testing.T) {
+	calls := 0
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			writeAPIError(w, http.StatusTooManyRequests, "Too Many Requests")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"items": [{"id": "dns%3A%2F%2Fexample.com", "site": {"identifier": "example.com", "type": "INET_DOMAIN"}}]}`)
+	})
+
+	domains, listErr := listVerifiedDomains(provider)
+	if listErr != nil {
+		t.Fatal(listErr)
+	}
+	if !domains["example.com"] || calls != 2 {
+		t.Errorf("expected example.com after a retry, got %v after %d calls", domains, calls)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com"}`)
+	})
+	provider.requestTimeout = 10 * time.Millisecond
+
+	if _, getErr := getWebResource(provider, "dns://example.com"); !errors.Is(getErr, context.DeadlineExceeded) {
+		t.Errorf("expected the call to time out, got %v", getErr)
+	}
+}
+
+func TestConfigureProviderBillingProject(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   map[string]interface{}
+		env      string
+		expected string
+	}{
+		{name: "none", expected: ""},
+		{name: "attribute", config: map[string]interface{}{billingProjectKey: "billing"}, expected: "billing"},
+		{name: "environment", env: "env-billing", expected: "env-billing"},
+		{name: "attribute over environment", config: map[string]interface{}{billingProjectKey: "billing"}, env: "env-billing", expected: "billing"},
+		{name: "custom transport", config: map[string]interface{}{billingProjectKey: "billing", requestTimeoutKey: "30s"}, expected: "billing"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Setenv("GOOGLE_CLOUD_QUOTA_PROJECT", testCase.env)
+			var quotaProject string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				quotaProject = r.Header.Get("X-Goog-User-Project")
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprint(w, `{"items": []}`)
+			}))
+			defer server.Close()
+
+			raw := map[string]interface{}{accessTokenKey: "access-token", endpointKey: server.URL + "/"}
+			for key, value := range testCase.config {
+				raw[key] = value
+			}
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, raw)
+			provider, configureErr := configureProvider(resourceData, "", context.Background())
+			if configureErr != nil {
+				t.Fatal(configureErr)
+			}
+			if _, listErr := listWebResources(provider.(configuredProvider)); listErr != nil {
+				t.Fatal(listErr)
+			}
+			if quotaProject != testCase.expected {
+				t.Errorf("expected the calls to be billed to %q, got %q", testCase.expected, quotaProject)
+			}
+		})
+	}
+}
+
+func TestConfigureProviderEndpoint(t *testing.T) {
+	for _, fromEnv := range []bool{false, true} {
+		t.Run(fmt.Sprintf("from environment %t", fromEnv), func(t *testing.T) {
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprint(w, `{"items": []}`)
+			}))
+			defer server.Close()
+
+			raw := map[string]interface{}{accessTokenKey: "access-token"}
+			if fromEnv {
+				t.Setenv("GOOGLE_SITE_VERIFICATION_CUSTOM_ENDPOINT", server.URL+"/psc/siteVerification/v1/")
+			} else {
+				raw[endpointKey] = server.URL + "/psc/siteVerification/v1/"
+			}
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, raw)
+			provider, configureErr := configureProvider(resourceData, "", context.Background())
+			if configureErr != nil {
+				t.Fatal(configureErr)
+			}
+			if _, listErr := listWebResources(provider.(configuredProvider)); listErr != nil {
+				t.Fatal(listErr)
+			}
+			if len(paths) != 1 || paths[0] != "/psc/siteVerification/v1/webResource" {
+				t.Errorf("expected the call to go to the endpoint, got %q", paths)
+			}
+			if basePath := provider.(configuredProvider).searchConsole.BasePath; strings.HasPrefix(basePath, server.URL) {
+				t.Errorf("expected Search Console to keep its default endpoint, got %s", basePath)
+			}
+		})
+	}
+
+	if _, errs := Provider().(*schema.Provider).Schema[endpointKey].ValidateFunc("www.googleapis.com/siteVerification/v1/", endpointKey); len(errs) == 0 {
+		t.Error("expected an endpoint without a scheme to be rejected")
+	}
+}
+
+func TestConfigureProviderUserAgent(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config map[string]interface{}
+	}{
+		{name: "default transport"},
+		{name: "custom transport", config: map[string]interface{}{requestTimeoutKey: "30s"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var agent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				agent = r.Header.Get("User-Agent")
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprint(w, `{"items": []}`)
+			}))
+			defer server.Close()
+
+			raw := map[string]interface{}{accessTokenKey: "access-token", endpointKey: server.URL + "/"}
+			for key, value := range testCase.config {
+				raw[key] = value
+			}
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, raw)
+			provider, configureErr := configureProvider(resourceData, "0.12.29", context.Background())
+			if configureErr != nil {
+				t.Fatal(configureErr)
+			}
+			if _, listErr := listWebResources(provider.(configuredProvider)); listErr != nil {
+				t.Fatal(listErr)
+			}
+			expected := "Terraform/0.12.29 (+https://www.terraform.io) terraform-provider-googlesiteverification/" + version
+			if !strings.Contains(agent, expected) {
+				t.Errorf("expected the user agent to contain %q, got %q", expected, agent)
+			}
+		})
+	}
+}
+
+func TestInterruptedInsertIsNotRetried(t *testing.T) {
+	stopContext, stop := context.WithCancel(context.Background())
+	calls := 0
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		stop()
+		writeAPIError(w, http.StatusServiceUnavailable, "Service Unavailable")
+	})
+	provider.stopContext = stopContext
+
+	if _, insertErr := insertSiteVerification(provider, time.Minute, "example.com", "DNS_TXT"); insertErr == nil {
+		t.Error("expected the interrupted verification to fail")
+	}
+	if calls != 1 {
+		t.Errorf("expected the interrupted verification not to be retried, got %d calls", calls)
+	}
+}
+
+func TestInterruptedCallsAreCancelled(t *testing.T) {
+	stopContext, stop := context.WithCancel(context.Background())
+	stop()
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s %s after the interruption", r.Method, r.URL.Path)
+	})
+	provider.stopContext = stopContext
+	provider.preflight = newPreflight(true)
+	records := []string{}
+	provider.cloudDNS = newTestCloudDNS(t, &records)
+
+	calls := map[string]func() error{
+		"list": func() error {
+			_, listErr := listWebResources(provider)
+			return listErr
+		},
+		"preflight": func() error {
+			return provider.preflight.check(provider)
+		},
+		"remove owners": func() error {
+			_, removeErr := removeOwners(provider, "dns://example.com", &siteverification.SiteVerificationWebResourceResource{Owners: []string{"a@example.com"}}, []string{"a@example.com"})
+			return removeErr
+		},
+		"TXT records": func() error {
+			_, findErr := findTXTRecordSet(provider, "project", "zone", "example.com.")
+			return findErr
+		},
+		"DNS change": func() error {
+			return applyDNSChange(provider, "project", "zone", &dns.Change{}, time.Minute)
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			if callErr := call(); !errors.Is(callErr, context.Canceled) {
+				t.Errorf("expected the call to be cancelled, got %v", callErr)
+			}
+		})
+	}
+}
+
+// externalAccountJSON returns external account credentials reading their subject token from a file,
+// to be exchanged at the given token URL.
+func externalAccountJSON(t *testing.T, tokenURL string) string {
+	subjectTokenFile := filepath.Join(t.TempDir(), "token")
+	if writeErr := os.WriteFile(subjectTokenFile, []byte("oidc-token"), 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	return fmt.Sprintf(`{
+		"type": "external_account",
+		"audience": "//iam.googleapis.com/pool",
+		"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+		"token_url": %q,
+		"credential_source": {"file": %q}
+	}`, tokenURL, subjectTokenFile)
+}
+
+func TestFindCredentialsScopes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "default",
+			expected: siteverification.SiteverificationScope + " " + webmasters.WebmastersReadonlyScope,
+		},
+		{
+			name:     "audit log",
+			config:   map[string]interface{}{auditLogNameKey: "projects/audit/logs/site-verification"},
+			expected: siteverification.SiteverificationScope + " " + webmasters.WebmastersReadonlyScope + " " + logging.LoggingWriteScope,
+		},
+		{
+			name:     "restricted",
+			config:   map[string]interface{}{scopesKey: []interface{}{siteverification.SiteverificationVerifyOnlyScope}},
+			expected: siteverification.SiteverificationVerifyOnlyScope,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var requestedScope string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestedScope = r.FormValue("scope")
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = fmt.Fprint(w, `{"access_token": "access-token", "expires_in": 3600}`)
+			}))
+			defer server.Close()
+
+			raw := map[string]interface{}{credentialsKey: externalAccountJSON(t, server.URL+"/token")}
+			for key, value := range testCase.config {
+				raw[key] = value
+			}
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, raw)
+			credentials, credentialsErr := findCredentials(resourceData, context.Background())
+			if credentialsErr != nil {
+				t.Fatal(credentialsErr)
+			}
+			if _, tokenErr := credentials.TokenSource.Token(); tokenErr != nil {
+				t.Fatal(tokenErr)
+			}
+			if requestedScope != testCase.expected {
+				t.Errorf("expected the scopes %q, got %q", testCase.expected, requestedScope)
+			}
+		})
+	}
+}
+
+func TestConfigureProviderCABundleAppliesToCredentials(t *testing.T) {
+	var tokenRequests int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			_, _ = fmt.Fprint(w, `{"access_token": "access-token", "expires_in": 3600}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+	caBundle := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, map[string]interface{}{
+		credentialsKey:         externalAccountJSON(t, server.URL+"/token"),
+		caBundleKey:            caBundle,
+		endpointKey:            server.URL + "/",
+		validateCredentialsKey: true,
+	})
+	if _, configureErr := configureProvider(resourceData, "", context.Background()); configureErr != nil {
+		t.Fatalf("expected the token endpoint to be trusted with the CA bundle, got %v", configureErr)
+	}
+	if tokenRequests == 0 {
+		t.Error("expected the credentials to get a token")
+	}
+}
+
+func TestConfigureProviderProxyAppliesToCredentials(t *testing.T) {
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "")
+	proxiedHosts := map[string]bool{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the hosts only exist behind the proxy
+		proxiedHosts[r.URL.Host] = true
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Host == "sts.invalid" {
+			_, _ = fmt.Fprint(w, `{"access_token": "access-token", "expires_in": 3600}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{}`)
+	}))
+	defer proxy.Close()
+
+	resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, map[string]interface{}{
+		credentialsKey:         externalAccountJSON(t, "http://sts.invalid/token"),
+		proxyURLKey:            proxy.URL,
+		endpointKey:            "http://api.invalid/",
+		validateCredentialsKey: true,
+	})
+	if _, configureErr := configureProvider(resourceData, "", context.Background()); configureErr != nil {
+		t.Fatalf("expected the token endpoint to be reached through the proxy, got %v", configureErr)
+	}
+	if !proxiedHosts["sts.invalid"] {
+		t.Errorf("expected the token request to go through the proxy, got requests for %v", proxiedHosts)
+	}
+}
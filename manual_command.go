@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/api/siteverification/v1"
+)
+
+const manualVerifyCommandKey = "manual_verify_command"
+const manualVerifyCommandStyleKey = "manual_verify_command_style"
+
+// manualVerifyCommand renders a shell command reproducing the verification API call of the web resource of the given type.
+// The access token is never embedded: depending on the style, it is obtained from gcloud
+// or expected in the ACCESS_TOKEN environment variable.
+func manualVerifyCommand(basePath string, style string, webResourceType string, identifier string, method string) string {
+	body, _ := json.Marshal(&siteverification.SiteVerificationWebResourceResource{
+		Site: &siteverification.SiteVerificationWebResourceResourceSite{
+			Identifier: identifier,
+			Type:       webResourceType,
+		},
+	})
+
+	accessToken := "$(gcloud auth print-access-token)"
+	if style == "curl" {
+		accessToken = "${ACCESS_TOKEN}"
+	}
+
+	return fmt.Sprintf(
+		`curl -X POST -H "Authorization: Bearer %s" -H "Content-Type: application/json" -d %s %s`,
+		accessToken,
+		shellQuote(string(body)),
+		shellQuote(fmt.Sprintf("%swebResource?verificationMethod=%s", basePath, url.QueryEscape(method))),
+	)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
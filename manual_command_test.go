@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestManualVerifyCommand(t *testing.T) {
+	testCases := []struct {
+		name            string
+		style           string
+		webResourceType string
+		identifier      string
+		method          string
+		expected        string
+	}{
+		{
+			name:            "gcloud domain",
+			style:           "gcloud",
+			webResourceType: "INET_DOMAIN",
+			identifier:      "example.com",
+			method:          "DNS_TXT",
+			expected:        `curl -X POST -H "Authorization: Bearer $(gcloud auth print-access-token)" -H "Content-Type: application/json" -d '{"site":{"identifier":"example.com","type":"INET_DOMAIN"}}' 'https://www.googleapis.com/siteVerification/v1/webResource?verificationMethod=DNS_TXT'`,
+		},
+		{
+			name:            "curl site",
+			style:           "curl",
+			webResourceType: "SITE",
+			identifier:      "https://example.com/",
+			method:          "META",
+			expected:        `curl -X POST -H "Authorization: Bearer ${ACCESS_TOKEN}" -H "Content-Type: application/json" -d '{"site":{"identifier":"https://example.com/","type":"SITE"}}' 'https://www.googleapis.com/siteVerification/v1/webResource?verificationMethod=META'`,
+		},
+		{
+			name:            "gcloud app",
+			style:           "gcloud",
+			webResourceType: "ANDROID_APP",
+			identifier:      "android-app://com.example.app",
+			method:          "META",
+			expected:        `curl -X POST -H "Authorization: Bearer $(gcloud auth print-access-token)" -H "Content-Type: application/json" -d '{"site":{"identifier":"android-app://com.example.app","type":"ANDROID_APP"}}' 'https://www.googleapis.com/siteVerification/v1/webResource?verificationMethod=META'`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := manualVerifyCommand("https://www.googleapis.com/siteVerification/v1/", testCase.style, testCase.webResourceType, testCase.identifier, testCase.method)
+			if actual != testCase.expected {
+				t.Errorf("expected\n%s\ngot\n%s", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if quoted := shellQuote(`it's`); quoted != `'it'\''s'` {
+		t.Errorf("expected the single quote to be escaped, got %s", quoted)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const metricsFileKey = "metrics_file"
+
+// metricsRetention is how long the metrics of an operation stay in the file after it last happened.
+const metricsRetention = 7 * 24 * time.Hour
+
+const metricsPrefix = "googlesiteverification_operation_"
+
+// metricsHelp describes the metrics written for each operation, by name.
+var metricsHelp = map[string]string{
+	metricsPrefix + "duration_seconds":  "How long the last operation on the domain took, retries included.",
+	metricsPrefix + "attempts":          "How many API calls the last operation on the domain needed.",
+	metricsPrefix + "success":           "Whether the last operation on the domain succeeded (1) or not (0).",
+	metricsPrefix + "timestamp_seconds": "When the last operation on the domain ended, as a Unix timestamp.",
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsRecorder writes the metrics of the verifications and unverifications to a file,
+// in the Prometheus text format, e.g. for the node exporter's textfile collector.
+// A nil metricsRecorder records nothing.
+type metricsRecorder struct {
+	path string
+	// mu serializes the writes of concurrent operations
+	mu sync.Mutex
+}
+
+func newMetricsRecorder(path string) *metricsRecorder {
+	if path == "" {
+		return nil
+	}
+	return &metricsRecorder{path: path}
+}
+
+// record adds the metrics of an operation to the file, replacing the previous ones for the same operation
+// and domain, and dropping the ones older than metricsRetention.
+// Failing to write the metrics is logged, but never fails the operation.
+func (recorder *metricsRecorder) record(operation string, domain string, duration time.Duration, attempts int, success bool) {
+	if recorder == nil {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	samples, readErr := readMetricsSamples(recorder.path)
+	if readErr != nil {
+		log.Printf("[WARN] could not read the metrics file %s, overwriting it: %s", recorder.path, readErr)
+		samples = map[string]map[string]float64{}
+	}
+
+	now := time.Now()
+	for labels, values := range samples {
+		if now.Sub(time.Unix(int64(values[metricsPrefix+"timestamp_seconds"]), 0)) > metricsRetention {
+			delete(samples, labels)
+		}
+	}
+
+	successValue := 0.0
+	if success {
+		successValue = 1
+	}
+	labels := fmt.Sprintf(`operation="%s",domain="%s"`, labelValueEscaper.Replace(operation), labelValueEscaper.Replace(domain))
+	samples[labels] = map[string]float64{
+		metricsPrefix + "duration_seconds":  duration.Seconds(),
+		metricsPrefix + "attempts":          float64(attempts),
+		metricsPrefix + "success":           successValue,
+		metricsPrefix + "timestamp_seconds": float64(now.Unix()),
+	}
+
+	if writeErr := writeMetricsSamples(recorder.path, samples); writeErr != nil {
+		log.Printf("[WARN] could not write the metrics file %s: %s", recorder.path, writeErr)
+	}
+}
+
+// readMetricsSamples reads the values of a metrics file previously written by writeMetricsSamples, by labels then name.
+func readMetricsSamples(path string) (map[string]map[string]float64, error) {
+	samples := map[string]map[string]float64{}
+
+	file, openErr := os.Open(path)
+	if errors.Is(openErr, fs.ErrNotExist) {
+		return samples, nil
+	}
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		labelsStart := strings.Index(line, "{")
+		labelsEnd := strings.LastIndex(line, "} ")
+		if labelsStart < 0 || labelsEnd < labelsStart {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+		value, parseErr := strconv.ParseFloat(line[labelsEnd+2:], 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("unexpected line %q: %w", line, parseErr)
+		}
+
+		labels := line[labelsStart+1 : labelsEnd]
+		if samples[labels] == nil {
+			samples[labels] = map[string]float64{}
+		}
+		samples[labels][line[:labelsStart]] = value
+	}
+	return samples, scanner.Err()
+}
+
+// writeMetricsSamples atomically replaces the metrics file, so its readers never see a partial file.
+func writeMetricsSamples(path string, samples map[string]map[string]float64) error {
+	var content strings.Builder
+
+	names := make([]string, 0, len(metricsHelp))
+	for name := range metricsHelp {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	labelsList := make([]string, 0, len(samples))
+	for labels := range samples {
+		labelsList = append(labelsList, labels)
+	}
+	sort.Strings(labelsList)
+
+	for _, name := range names {
+		_, _ = fmt.Fprintf(&content, "# HELP %s %s\n# TYPE %s gauge\n", name, metricsHelp[name], name)
+		for _, labels := range labelsList {
+			if value, ok := samples[labels][name]; ok {
+				_, _ = fmt.Fprintf(&content, "%s{%s} %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+			}
+		}
+	}
+
+	temporaryFile, createErr := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if createErr != nil {
+		return createErr
+	}
+	defer os.Remove(temporaryFile.Name())
+
+	if _, writeErr := temporaryFile.WriteString(content.String()); writeErr != nil {
+		_ = temporaryFile.Close()
+		return writeErr
+	}
+	if closeErr := temporaryFile.Close(); closeErr != nil {
+		return closeErr
+	}
+	if chmodErr := os.Chmod(temporaryFile.Name(), 0644); chmodErr != nil {
+		return chmodErr
+	}
+	return os.Rename(temporaryFile.Name(), path)
+}
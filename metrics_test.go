@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "googlesiteverification.prom")
+
+	stale := map[string]map[string]float64{
+		`operation="create",domain="old.example.com"`: {
+			metricsPrefix + "timestamp_seconds": float64(time.Now().Add(-metricsRetention - time.Hour).Unix()),
+		},
+	}
+	if writeErr := writeMetricsSamples(path, stale); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	recorder := newMetricsRecorder(path)
+	recorder.record("create", "example.com", 90*time.Second, 3, true)
+	recorder.record("create", "other.example.com", time.Second, 1, false)
+	recorder.record("create", "example.com", 30*time.Second, 2, true)
+
+	content, readErr := os.ReadFile(path)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	for _, expected := range []string{
+		`googlesiteverification_operation_duration_seconds{operation="create",domain="example.com"} 30`,
+		`googlesiteverification_operation_attempts{operation="create",domain="example.com"} 2`,
+		`googlesiteverification_operation_success{operation="create",domain="example.com"} 1`,
+		`googlesiteverification_operation_success{operation="create",domain="other.example.com"} 0`,
+		"# TYPE googlesiteverification_operation_attempts gauge",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected the metrics to contain %q, got:\n%s", expected, content)
+		}
+	}
+	if strings.Contains(string(content), "old.example.com") {
+		t.Errorf("expected the stale metrics to be removed, got:\n%s", content)
+	}
+}
+
+func TestNilMetricsRecorder(t *testing.T) {
+	// recording must be a no-op when metrics are disabled
+	newMetricsRecorder("").record("create", "example.com", time.Second, 1, true)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const verifiedKey = "verified"
+
+func dnsMonitorResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainKey: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The domain to monitor. It is expected to be verified by other means, e.g. a `googlesiteverification_dns` resource in another configuration.",
+			},
+			verifiedKey: {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the domain was still verified when last refreshed.",
+			},
+		},
+		Create:      createDnsMonitor,
+		Read:        readDnsMonitor,
+		Delete:      deleteDnsMonitor,
+		Description: "Checks on every refresh that a domain is still verified, without ever verifying or unverifying it. Use `googlesiteverification_dns` to manage the verification itself.",
+	}
+}
+
+func createDnsMonitor(resourceData *schema.ResourceData, provider interface{}) error {
+	resourceData.SetId(fmt.Sprintf("dns://%s", resourceData.Get(domainKey).(string)))
+
+	return readDnsMonitor(resourceData, provider)
+}
+
+func readDnsMonitor(resourceData *schema.ResourceData, provider interface{}) error {
+	verified := true
+	_, getErr := getWebResource(provider.(configuredProvider), resourceData.Id())
+	if getErr != nil {
+		if httpStatusCode(getErr) != http.StatusNotFound {
+			return getErr
+		}
+		log.Printf("[WARN] %s is not verified anymore", resourceData.Get(domainKey).(string))
+		verified = false
+	}
+
+	return resourceData.Set(verifiedKey, verified)
+}
+
+func deleteDnsMonitor(resourceData *schema.ResourceData, provider interface{}) error {
+	// the verification is managed elsewhere: forgetting about it is all there is to do
+	resourceData.SetId("")
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestDnsMonitor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected bool
+		fails    bool
+	}{
+		{
+			name:     "verified",
+			handler:  verifiedHandler,
+			expected: true,
+		},
+		{
+			name: "not verified anymore",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeAPIError(w, http.StatusNotFound, "Not Found")
+			},
+			expected: false,
+		},
+		{
+			name: "forbidden",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeAPIError(w, http.StatusForbidden, "Forbidden")
+			},
+			fails: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("expected the monitor to only read the verification, got a %s request", r.Method)
+				}
+				testCase.handler(w, r)
+			})
+			resourceData := schema.TestResourceDataRaw(t, dnsMonitorResource().Schema, map[string]interface{}{domainKey: "example.com"})
+
+			createErr := createDnsMonitor(resourceData, provider)
+			if testCase.fails {
+				if createErr == nil {
+					t.Error("expected the monitor to fail")
+				}
+				return
+			}
+			if createErr != nil {
+				t.Fatal(createErr)
+			}
+			if resourceData.Id() != "dns://example.com" {
+				t.Errorf("expected the id dns://example.com, got %q", resourceData.Id())
+			}
+			if verified := resourceData.Get(verifiedKey).(bool); verified != testCase.expected {
+				t.Errorf("expected verified to be %t, got %t", testCase.expected, verified)
+			}
+
+			if deleteErr := deleteDnsMonitor(resourceData, provider); deleteErr != nil {
+				t.Fatal(deleteErr)
+			}
+			if resourceData.Id() != "" {
+				t.Errorf("expected the monitor to be forgotten, got the id %q", resourceData.Id())
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/siteverification/v1"
+)
+
+const maxOwnersKey = "max_owners"
+const disallowedOwnersKey = "disallowed_owners"
+const remediateOwnersKey = "remediate_owners"
+const ownerPolicyViolationsKey = "owner_policy_violations"
+
+// ownerPolicy restricts who, and how many, may own a verified domain.
+type ownerPolicy struct {
+	// maxOwners is 0 when there is no maximum
+	maxOwners  int
+	disallowed map[string]bool
+	// managingIdentity is never reported nor removed, whatever the policy says
+	managingIdentity string
+}
+
+// violations returns the owners breaking the policy, in their original order.
+// The disallowed owners come first, then the owners above the maximum.
+func (policy ownerPolicy) violations(owners []string) []string {
+	var allowed, violations []string
+	for _, owner := range owners {
+		if policy.disallowed[strings.ToLower(owner)] && !policy.isManagingIdentity(owner) {
+			violations = append(violations, owner)
+		} else {
+			allowed = append(allowed, owner)
+		}
+	}
+
+	if policy.maxOwners == 0 || len(allowed) <= policy.maxOwners {
+		return violations
+	}
+
+	// the managing identity takes one of the allowed places, whatever its position
+	kept := 0
+	for _, owner := range allowed {
+		if policy.isManagingIdentity(owner) {
+			kept++
+		}
+	}
+	for _, owner := range allowed {
+		if policy.isManagingIdentity(owner) {
+			continue
+		}
+		if kept < policy.maxOwners {
+			kept++
+			continue
+		}
+		violations = append(violations, owner)
+	}
+	return violations
+}
+
+func (policy ownerPolicy) isManagingIdentity(owner string) bool {
+	return policy.managingIdentity != "" && strings.EqualFold(owner, policy.managingIdentity)
+}
+
+// enforceOwnerPolicy reports the owners breaking the policy of the resource and, if remediation is enabled,
+// removes them from the web resource. It returns the web resource as it is after any remediation.
+func enforceOwnerPolicy(resourceData *schema.ResourceData, provider configuredProvider, webResource *siteverification.SiteVerificationWebResourceResource) (*siteverification.SiteVerificationWebResourceResource, error) {
+	policy := ownerPolicy{
+		maxOwners:        resourceData.Get(maxOwnersKey).(int),
+		disallowed:       map[string]bool{},
+		managingIdentity: provider.managingIdentity,
+	}
+	for _, owner := range resourceData.Get(disallowedOwnersKey).(*schema.Set).List() {
+		policy.disallowed[strings.ToLower(owner.(string))] = true
+	}
+
+	violations := policy.violations(webResource.Owners)
+	if len(violations) > 0 && resourceData.Get(remediateOwnersKey).(bool) {
+		if provider.readOnly {
+			log.Printf("[WARN] not removing the owners breaking the policy of %s: the provider is read-only", resourceData.Id())
+		} else if provider.managingIdentity == "" {
+			log.Printf("[WARN] not removing the owners breaking the policy of %s: the identity of the provider's credentials is unknown, so it could be removed too", resourceData.Id())
+		} else {
+			remediated, remediateErr := removeOwners(provider, resourceData.Id(), webResource, violations)
+			if remediateErr != nil {
+				return nil, fmt.Errorf("removing the owners breaking the policy of %s: %w", resourceData.Id(), remediateErr)
+			}
+			webResource = remediated
+			violations = policy.violations(webResource.Owners)
+		}
+	}
+
+	if setErr := resourceData.Set(ownerPolicyViolationsKey, violations); setErr != nil {
+		return nil, setErr
+	}
+	return webResource, nil
+}
+
+// removeOwners updates the web resource without the given owners.
+func removeOwners(provider configuredProvider, id string, webResource *siteverification.SiteVerificationWebResourceResource, owners []string) (*siteverification.SiteVerificationWebResourceResource, error) {
+	removed := map[string]bool{}
+	for _, owner := range owners {
+		removed[owner] = true
+	}
+
+	var remaining []string
+	for _, owner := range webResource.Owners {
+		if removed[owner] {
+			log.Printf("[INFO] removing %s from the owners of %s, as it breaks the owner policy", owner, id)
+		} else {
+			remaining = append(remaining, owner)
+		}
+	}
+
+	ctx, cancel := provider.requestContext()
+	defer cancel()
+	return provider.service.WebResource.Update(id, &siteverification.SiteVerificationWebResourceResource{
+		Id:     webResource.Id,
+		Owners: remaining,
+		Site:   webResource.Site,
+	}).Context(ctx).Do()
+}
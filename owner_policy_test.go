@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOwnerPolicyViolations(t *testing.T) {
+	const manager = "terraform@project.iam.gserviceaccount.com"
+
+	testCases := []struct {
+		name     string
+		policy   ownerPolicy
+		owners   []string
+		expected []string
+	}{
+		{
+			name:     "no policy",
+			policy:   ownerPolicy{managingIdentity: manager},
+			owners:   []string{manager, "a@example.com", "b@example.com"},
+			expected: nil,
+		},
+		{
+			name:     "disallowed owner",
+			policy:   ownerPolicy{disallowed: map[string]bool{"b@example.com": true}, managingIdentity: manager},
+			owners:   []string{manager, "a@example.com", "B@example.com"},
+			expected: []string{"B@example.com"},
+		},
+		{
+			name:     "above the maximum",
+			policy:   ownerPolicy{maxOwners: 2, managingIdentity: manager},
+			owners:   []string{"a@example.com", "b@example.com", manager},
+			expected: []string{"b@example.com"},
+		},
+		{
+			name:     "disallowed and above the maximum",
+			policy:   ownerPolicy{maxOwners: 1, disallowed: map[string]bool{"a@example.com": true}, managingIdentity: manager},
+			owners:   []string{"a@example.com", "b@example.com", manager},
+			expected: []string{"a@example.com", "b@example.com"},
+		},
+		{
+			name:     "managing identity disallowed",
+			policy:   ownerPolicy{disallowed: map[string]bool{manager: true}, managingIdentity: manager},
+			owners:   []string{manager, "a@example.com"},
+			expected: nil,
+		},
+		{
+			name:     "unknown managing identity",
+			policy:   ownerPolicy{maxOwners: 1},
+			owners:   []string{"a@example.com", "b@example.com"},
+			expected: []string{"b@example.com"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := testCase.policy.violations(testCase.owners); !reflect.DeepEqual(actual, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/siteverification/v1"
+)
+
+const ownerCountKey = "owner_count"
+const primaryOwnerKey = "primary_owner"
+
+func dnsDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainKey: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The verified domain, e.g. `example.com`.",
+			},
+			ownersKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The emails of the owners of the domain, including the delegated ones. Empty if Google returns no owner.",
+			},
+		},
+		Description: "https://developers.google.com/site-verification/v1/webResource/get\n\nReads a verified domain, whether Terraform manages its verification or not, e.g. to audit who still owns it.",
+		Read:        readDnsDataSource,
+	}
+}
+
+func readDnsDataSource(resourceData *schema.ResourceData, provider interface{}) error {
+	id := webResourceID(bareDomain(resourceData.Get(domainKey).(string)))
+
+	webResource, getErr := getWebResource(provider.(configuredProvider), id)
+	if getErr != nil {
+		return getErr
+	}
+
+	owners := webResource.Owners
+	if owners == nil {
+		owners = []string{}
+	}
+	if setErr := resourceData.Set(ownersKey, owners); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId(id)
+
+	return nil
+}
+
+// ownersList converts the owners attribute to a list of emails.
+func ownersList(owners interface{}) []string {
+	list := []string{}
+	for _, owner := range owners.([]interface{}) {
+		list = append(list, owner.(string))
+	}
+	return list
+}
+
+// uniqueOwners returns the owners without their duplicates, compared case-insensitively, keeping the first spelling.
+func uniqueOwners(owners []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, owner := range owners {
+		if normalized := strings.ToLower(strings.TrimSpace(owner)); !seen[normalized] {
+			seen[normalized] = true
+			unique = append(unique, owner)
+		}
+	}
+	return unique
+}
+
+// validateOwnerEmail rejects the owners which are not bare email addresses, e.g. with a display name or a typo,
+// which the API refuses with an error that does not tell which one is wrong.
+func validateOwnerEmail(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	address, parseErr := mail.ParseAddress(v)
+	if parseErr != nil || address.Address != v || address.Name != "" {
+		return nil, []error{fmt.Errorf("%s: expected an email address such as someone@example.com, got %q", k, v)}
+	}
+	return nil, nil
+}
+
+// primaryOwner returns the managing identity if it is one of the owners, the first owner otherwise,
+// or an empty string when there is no owner.
+func primaryOwner(owners []string, managingIdentity string) string {
+	if len(owners) == 0 {
+		return ""
+	}
+	for _, owner := range owners {
+		if managingIdentity != "" && strings.EqualFold(owner, managingIdentity) {
+			return owner
+		}
+	}
+	return owners[0]
+}
+
+// checkOwners returns an error if the owners would lock the provider out of the web resource.
+func (provider configuredProvider) checkOwners(owners []string) error {
+	if len(owners) == 0 {
+		return errors.New("a verified domain must keep at least one owner: removing the last one would lock everybody out of it")
+	}
+	if provider.managingIdentity == "" {
+		return nil
+	}
+	for _, owner := range owners {
+		if strings.EqualFold(owner, provider.managingIdentity) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the owners must include %s, the identity of the provider's credentials: removing it would prevent the provider from managing the domain", provider.managingIdentity)
+}
+
+// updateOwners replaces the owners of the web resource, without verifying it again.
+func updateOwners(provider configuredProvider, id string, owners []string) error {
+	if writableErr := provider.checkWritable("update the owners of " + id); writableErr != nil {
+		return writableErr
+	}
+	if ownersErr := provider.checkOwners(owners); ownersErr != nil {
+		return ownersErr
+	}
+
+	webResource, getErr := getWebResource(provider, id)
+	if getErr != nil {
+		return getErr
+	}
+
+	log.Printf("[INFO] updating the owners of %s from %v to %v", id, webResource.Owners, owners)
+	ctx, cancel := provider.requestContext()
+	defer cancel()
+	_, updateErr := provider.service.WebResource.Update(id, &siteverification.SiteVerificationWebResourceResource{
+		Id:     webResource.Id,
+		Owners: owners,
+		Site:   webResource.Site,
+	}).Context(ctx).Do()
+	if updateErr != nil {
+		return fmt.Errorf("updating the owners of %s: %w", id, updateErr)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestCheckOwners(t *testing.T) {
+	provider := configuredProvider{managingIdentity: "terraform@project.iam.gserviceaccount.com"}
+
+	if ownersErr := provider.checkOwners([]string{"a@example.com", "Terraform@project.iam.gserviceaccount.com"}); ownersErr != nil {
+		t.Errorf("expected owners keeping the managing identity to be accepted, got %s", ownersErr)
+	}
+	if ownersErr := provider.checkOwners([]string{"a@example.com"}); ownersErr == nil {
+		t.Error("expected owners without the managing identity to be rejected")
+	}
+	if ownersErr := provider.checkOwners([]string{}); ownersErr == nil {
+		t.Error("expected removing the last owner to be rejected")
+	}
+	if ownersErr := (configuredProvider{}).checkOwners([]string{"a@example.com"}); ownersErr != nil {
+		t.Errorf("expected any owner to be accepted when the managing identity is unknown, got %s", ownersErr)
+	}
+}
+
+func TestPrimaryOwner(t *testing.T) {
+	managingIdentity := "terraform@project.iam.gserviceaccount.com"
+
+	if owner := primaryOwner([]string{"a@example.com", "Terraform@project.iam.gserviceaccount.com"}, managingIdentity); owner != "Terraform@project.iam.gserviceaccount.com" {
+		t.Errorf("expected the managing identity, got %q", owner)
+	}
+	if owner := primaryOwner([]string{"a@example.com", "b@example.com"}, managingIdentity); owner != "a@example.com" {
+		t.Errorf("expected the first owner, got %q", owner)
+	}
+	if owner := primaryOwner([]string{"a@example.com"}, ""); owner != "a@example.com" {
+		t.Errorf("expected the first owner when the managing identity is unknown, got %q", owner)
+	}
+	if owner := primaryOwner(nil, managingIdentity); owner != "" {
+		t.Errorf("expected no owner, got %q", owner)
+	}
+}
+
+func TestUniqueOwners(t *testing.T) {
+	owners := uniqueOwners([]string{"a@example.com", "b@example.com", "A@example.com", "a@example.com"})
+	if expected := []string{"a@example.com", "b@example.com"}; !reflect.DeepEqual(owners, expected) {
+		t.Errorf("expected %q, got %q", expected, owners)
+	}
+}
+
+func TestValidateOwnerEmail(t *testing.T) {
+	testCases := []struct {
+		owner string
+		valid bool
+	}{
+		{owner: "someone@example.com", valid: true},
+		{owner: "terraform@project.iam.gserviceaccount.com", valid: true},
+		{owner: "someone", valid: false},
+		{owner: "someone@", valid: false},
+		{owner: "Someone <someone@example.com>", valid: false},
+		{owner: " someone@example.com", valid: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.owner, func(t *testing.T) {
+			_, errs := validateOwnerEmail(testCase.owner, "owners.1")
+			if (len(errs) == 0) != testCase.valid {
+				t.Fatalf("expected valid=%t, got %v", testCase.valid, errs)
+			}
+			if len(errs) > 0 && !strings.Contains(errs[0].Error(), "owners.1") {
+				t.Errorf("expected the error to tell which entry is invalid, got %q", errs[0])
+			}
+		})
+	}
+}
+
+func TestReadDnsDataSourceNormalizesDomain(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/webResource/dns://example.com" {
+			t.Errorf("expected the web resource of example.com to be read, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "owners": ["a@example.com"]}`)
+	})
+
+	for _, domain := range []string{"example.com", "Example.com", "sc-domain:example.com"} {
+		t.Run(domain, func(t *testing.T) {
+			if _, validateErrs := validateIdentifier(domain, domainKey); len(validateErrs) > 0 {
+				t.Fatal(validateErrs)
+			}
+			resourceData := schema.TestResourceDataRaw(t, dnsDataSource().Schema, map[string]interface{}{domainKey: domain})
+			if readErr := readDnsDataSource(resourceData, provider); readErr != nil {
+				t.Fatal(readErr)
+			}
+			if id := resourceData.Id(); id != "dns://example.com" {
+				t.Errorf("expected the id dns://example.com, got %s", id)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/siteverification/v1"
+)
+
+const preflightCheckKey = "preflight_check"
+const missingPermissionsKey = "missing_permissions"
+const okKey = "ok"
+
+// The Site Verification API has no testIamPermissions method: ownership is granted per domain, not through IAM.
+// The preflight check approximates it with the cheapest authorized call, listing the verified resources,
+// and maps the reasons of a refusal to what is missing.
+const (
+	missingScope      = "the " + siteverification.SiteverificationScope + " OAuth scope"
+	missingAPI        = "the Site Verification API enabled in the project of the credentials"
+	missingPermission = "the permission to use the Site Verification API"
+	invalidCredential = "valid credentials"
+)
+
+// preflight runs the preflight check once, however many verifications are created.
+type preflight struct {
+	once sync.Once
+	err  error
+}
+
+func newPreflight(enabled bool) *preflight {
+	if !enabled {
+		return nil
+	}
+	return &preflight{}
+}
+
+// check returns an error listing what the credentials miss, if anything, the first time it is called.
+func (p *preflight) check(provider configuredProvider) error {
+	if p == nil {
+		return nil
+	}
+	p.once.Do(func() {
+		ctx, cancel := provider.requestContext()
+		defer cancel()
+		missing, checkErr := missingPermissions(ctx, provider.service)
+		if checkErr != nil {
+			p.err = fmt.Errorf("preflight check: %w", checkErr)
+		} else if len(missing) > 0 {
+			p.err = fmt.Errorf("preflight check: the credentials are missing %s", strings.Join(missing, ", "))
+		}
+	})
+	return p.err
+}
+
+// missingPermissions returns what the credentials miss to use the Site Verification API.
+// Errors which don't tell anything about the credentials, such as network errors, are returned as is.
+func missingPermissions(ctx context.Context, service *siteverification.Service) ([]string, error) {
+	_, listErr := service.WebResource.List().Context(ctx).Do()
+	if listErr == nil {
+		return nil, nil
+	}
+
+	var apiErr *googleapi.Error
+	if !errors.As(listErr, &apiErr) {
+		return nil, listErr
+	}
+	switch apiErr.Code {
+	case http.StatusUnauthorized:
+		return []string{invalidCredential}, nil
+	case http.StatusForbidden:
+	default:
+		return nil, listErr
+	}
+
+	missing := map[string]bool{}
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "insufficientPermissions", "ACCESS_TOKEN_SCOPE_INSUFFICIENT":
+			missing[missingScope] = true
+		case "accessNotConfigured", "SERVICE_DISABLED":
+			missing[missingAPI] = true
+		}
+	}
+	if len(missing) == 0 {
+		return []string{missingPermission}, nil
+	}
+
+	result := make([]string, 0, len(missing))
+	for _, requirement := range []string{missingScope, missingAPI} {
+		if missing[requirement] {
+			result = append(result, requirement)
+		}
+	}
+	return result, nil
+}
+
+func authCheckDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			missingPermissionsKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "What the credentials miss to verify domains, e.g. an OAuth scope or the Site Verification API being enabled. Empty when nothing is missing.",
+			},
+			okKey: {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the credentials can use the Site Verification API.",
+			},
+		},
+		Description: "Checks whether the provider's credentials can use the Site Verification API, without verifying anything. The API has no way to test permissions, so this lists the verified resources and reports what a refusal says is missing.",
+		Read:        readAuthCheck,
+	}
+}
+
+func readAuthCheck(resourceData *schema.ResourceData, provider interface{}) error {
+	ctx, cancel := provider.(configuredProvider).requestContext()
+	defer cancel()
+	missing, checkErr := missingPermissions(ctx, provider.(configuredProvider).service)
+	if checkErr != nil {
+		return checkErr
+	}
+
+	if setErr := resourceData.Set(missingPermissionsKey, missing); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(okKey, len(missing) == 0); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId("auth_check")
+
+	return nil
+}
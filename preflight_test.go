@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/siteverification/v1"
+)
+
+func TestMissingPermissions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		code     int
+		reason   string
+		expected []string
+	}{
+		{name: "allowed", code: http.StatusOK, expected: nil},
+		{name: "insufficient scopes", code: http.StatusForbidden, reason: "insufficientPermissions", expected: []string{missingScope}},
+		{name: "API disabled", code: http.StatusForbidden, reason: "accessNotConfigured", expected: []string{missingAPI}},
+		{name: "other refusal", code: http.StatusForbidden, reason: "forbidden", expected: []string{missingPermission}},
+		{name: "invalid credentials", code: http.StatusUnauthorized, reason: "authError", expected: []string{invalidCredential}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(testCase.code)
+				if testCase.code == http.StatusOK {
+					_, _ = fmt.Fprint(w, `{"items": []}`)
+					return
+				}
+				_, _ = fmt.Fprintf(w, `{"error": {"code": %d, "message": "refused", "errors": [{"reason": %q}]}}`, testCase.code, testCase.reason)
+			})
+
+			missing, checkErr := missingPermissions(context.Background(), provider.service)
+			if checkErr != nil {
+				t.Fatal(checkErr)
+			}
+			if !reflect.DeepEqual(missing, testCase.expected) {
+				t.Errorf("expected %q, got %q", testCase.expected, missing)
+			}
+		})
+	}
+}
+
+func TestMissingPermissionsServerError(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusInternalServerError, "Internal Error")
+	})
+
+	if _, checkErr := missingPermissions(context.Background(), provider.service); checkErr == nil {
+		t.Error("expected a server error not to be reported as missing permissions")
+	}
+}
+
+func TestPreflightSkippedWithVerifyOnlyScopes(t *testing.T) {
+	testCases := []struct {
+		scopes   []interface{}
+		expected bool
+	}{
+		{scopes: nil, expected: true},
+		{scopes: []interface{}{siteverification.SiteverificationScope}, expected: true},
+		{scopes: []interface{}{siteverification.SiteverificationVerifyOnlyScope}, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprint(testCase.scopes), func(t *testing.T) {
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).Schema, map[string]interface{}{
+				accessTokenKey:    "access-token",
+				scopesKey:         testCase.scopes,
+				preflightCheckKey: true,
+			})
+			provider, configureErr := configureProvider(resourceData, "", context.Background())
+			if configureErr != nil {
+				t.Fatal(configureErr)
+			}
+			if enabled := provider.(configuredProvider).preflight != nil; enabled != testCase.expected {
+				t.Errorf("expected the preflight check to be enabled=%t, got %t", testCase.expected, enabled)
+			}
+		})
+	}
+}
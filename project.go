@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"golang.org/x/oauth2/google"
+)
+
+const projectIDKey = "project_id"
+const quotaProjectIDKey = "quota_project_id"
+
+// credentialsProject holds the projects the credentials resolve to, empty when they don't tell.
+type credentialsProject struct {
+	ProjectID      string `json:"project_id"`
+	QuotaProjectID string `json:"quota_project_id"`
+}
+
+// credentialsProjects returns the projects of the credentials: the one the credentials found, e.g. by
+// google.FindDefaultCredentials from the key file or the metadata server, or else the one of their JSON,
+// and the quota project of the user credentials. Credentials which can't be parsed tell no project.
+func credentialsProjects(credentials *google.Credentials) credentialsProject {
+	var project credentialsProject
+	if credentials == nil {
+		return project
+	}
+	if len(credentials.JSON) > 0 && json.Unmarshal(credentials.JSON, &project) != nil {
+		project = credentialsProject{}
+	}
+	if credentials.ProjectID != "" {
+		project.ProjectID = credentials.ProjectID
+	}
+	return project
+}
+
+func projectDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			projectIDKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The project the provider's credentials resolve to, e.g. the one of the service account key or of the metadata server with the application default credentials. Empty when they don't tell, e.g. for user credentials.",
+			},
+			quotaProjectIDKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The quota project of the user credentials, as set by `gcloud auth application-default set-quota-project`. Empty for the other credentials. The `billing_project` of the provider takes precedence over it.",
+			},
+		},
+		Description: "Tells which project the provider's credentials resolve to, without calling any API, e.g. to check that a CI pipeline authenticates as intended before verifying anything.",
+		Read:        readProject,
+	}
+}
+
+func readProject(resourceData *schema.ResourceData, provider interface{}) error {
+	project := provider.(configuredProvider).project
+
+	if setErr := resourceData.Set(projectIDKey, project.ProjectID); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(quotaProjectIDKey, project.QuotaProjectID); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId("project")
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/oauth2/google"
+)
+
+func TestCredentialsProjects(t *testing.T) {
+	testCases := []struct {
+		name        string
+		credentials *google.Credentials
+		expected    credentialsProject
+	}{
+		{
+			name:        "service account key",
+			credentials: &google.Credentials{ProjectID: "my-project", JSON: []byte(`{"type": "service_account", "project_id": "my-project"}`)},
+			expected:    credentialsProject{ProjectID: "my-project"},
+		},
+		{
+			name:        "user credentials with a quota project",
+			credentials: &google.Credentials{JSON: []byte(`{"type": "authorized_user", "quota_project_id": "my-quota-project"}`)},
+			expected:    credentialsProject{QuotaProjectID: "my-quota-project"},
+		},
+		{
+			name:        "metadata server",
+			credentials: &google.Credentials{ProjectID: "my-project"},
+			expected:    credentialsProject{ProjectID: "my-project"},
+		},
+		{
+			name:        "unexpected JSON",
+			credentials: &google.Credentials{JSON: []byte(`{"project_id": 42}`)},
+			expected:    credentialsProject{},
+		},
+		{
+			name:     "no credentials",
+			expected: credentialsProject{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := credentialsProjects(testCase.credentials); actual != testCase.expected {
+				t.Errorf("expected %+v, got %+v", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const propertyTypeKey = "property_type"
+
+// The types of Search Console properties, as Search Console names them.
+const domainPropertyType = "DOMAIN"
+const urlPrefixPropertyType = "URL_PREFIX"
+
+// scDomainPrefix starts the identifiers of the domain properties in Search Console, e.g. sc-domain:example.com.
+const scDomainPrefix = "sc-domain:"
+
+var propertyTypes = []string{domainPropertyType, urlPrefixPropertyType}
+
+// propertyIdentifier returns the type and the identifier of the web resource verifying a Search Console property:
+// a DOMAIN property, e.g. sc-domain:example.com, is the INET_DOMAIN example.com,
+// and a URL_PREFIX property, e.g. http://192.0.2.1/, is the SITE of its canonical URL.
+// Identifiers starting with sc-domain: are DOMAIN properties when no property type is given.
+// Without any, the type returned is empty and the identifier is kept as is.
+func propertyIdentifier(propertyType string, identifier string) (string, string, error) {
+	if propertyType == "" && strings.HasPrefix(strings.ToLower(identifier), scDomainPrefix) {
+		propertyType = domainPropertyType
+	}
+
+	switch propertyType {
+	case "":
+		return "", identifier, nil
+	case domainPropertyType:
+		domain := strings.ToLower(identifier)
+		domain = strings.TrimPrefix(domain, scDomainPrefix)
+		if !bareDomainRegexp.MatchString(domain) {
+			return "", "", fmt.Errorf("expected a domain property such as sc-domain:example.com or example.com, got %q", identifier)
+		}
+		return siteType, domain, nil
+	case urlPrefixPropertyType:
+		site, canonicalErr := canonicalSiteURL(identifier)
+		if canonicalErr != nil {
+			return "", "", canonicalErr
+		}
+		return urlSiteType, site, nil
+	default:
+		return "", "", fmt.Errorf("unknown property type %q", propertyType)
+	}
+}
+
+// bareDomain returns the domain of an identifier validateIdentifier accepted for a domain-only attribute,
+// in the form Google and the DNS zones know it: lowercase, without the sc-domain: prefix of Search Console.
+func bareDomain(identifier string) string {
+	return strings.TrimPrefix(strings.ToLower(identifier), scDomainPrefix)
+}
+
+// resourceIdentifier returns the identifier of the web resource to verify: the domain as configured,
+// a site URL in its canonical form, or the identifier of the web resource verifying its property_type.
+// Invalid properties are reported by the diff, they are kept as is here.
+func resourceIdentifier(resourceData *schema.ResourceData) string {
+	domain := resourceData.Get(domainKey).(string)
+	webResourceType, identifier, propertyErr := propertyIdentifier(resourceData.Get(propertyTypeKey).(string), domain)
+	if propertyErr != nil {
+		return domain
+	}
+	if webResourceType == "" {
+		// only the http and https URLs have a canonical form, the domains and the apps are kept as is
+		if site, canonicalErr := canonicalSiteURL(identifier); canonicalErr == nil {
+			return site
+		}
+	}
+	return identifier
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestPropertyIdentifier(t *testing.T) {
+	testCases := []struct {
+		propertyType            string
+		identifier              string
+		expectedWebResourceType string
+		expectedIdentifier      string
+		valid                   bool
+	}{
+		{propertyType: "", identifier: "example.com", expectedWebResourceType: "", expectedIdentifier: "example.com", valid: true},
+		{propertyType: "", identifier: "sc-domain:example.com", expectedWebResourceType: "INET_DOMAIN", expectedIdentifier: "example.com", valid: true},
+		{propertyType: "DOMAIN", identifier: "sc-domain:Example.com", expectedWebResourceType: "INET_DOMAIN", expectedIdentifier: "example.com", valid: true},
+		{propertyType: "DOMAIN", identifier: "example.com", expectedWebResourceType: "INET_DOMAIN", expectedIdentifier: "example.com", valid: true},
+		{propertyType: "DOMAIN", identifier: "https://example.com/", valid: false},
+		{propertyType: "URL_PREFIX", identifier: "http://192.0.2.1", expectedWebResourceType: "SITE", expectedIdentifier: "http://192.0.2.1/", valid: true},
+		{propertyType: "URL_PREFIX", identifier: "https://Example.com:443/blog", expectedWebResourceType: "SITE", expectedIdentifier: "https://example.com/blog/", valid: true},
+		{propertyType: "URL_PREFIX", identifier: "sc-domain:example.com", valid: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.propertyType+" "+testCase.identifier, func(t *testing.T) {
+			webResourceType, identifier, err := propertyIdentifier(testCase.propertyType, testCase.identifier)
+			if (err == nil) != testCase.valid {
+				t.Fatalf("expected valid=%t, got %v", testCase.valid, err)
+			}
+			if webResourceType != testCase.expectedWebResourceType || identifier != testCase.expectedIdentifier {
+				t.Errorf("expected %q %q, got %q %q", testCase.expectedWebResourceType, testCase.expectedIdentifier, webResourceType, identifier)
+			}
+		})
+	}
+}
+
+func TestResourceIdentifier(t *testing.T) {
+	testCases := []struct {
+		domain   string
+		expected string
+	}{
+		{domain: "example.com", expected: "example.com"},
+		{domain: "sc-domain:Example.com", expected: "example.com"},
+		{domain: "https://Example.com:443", expected: "https://example.com/"},
+		{domain: "android-app://com.example.app", expected: "android-app://com.example.app"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.domain, func(t *testing.T) {
+			resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"].Schema, map[string]interface{}{
+				domainKey: testCase.domain,
+				tokenKey:  "google-site-verification=abc",
+			})
+			if actual := resourceIdentifier(resourceData); actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const resultKey = "result"
+const durationSecondsKey = "duration_seconds"
+
+func resultSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				idKey: {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The id of the verified web resource.",
+				},
+				domainKey: {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The verified domain.",
+				},
+				verifiedKey: {
+					Type:        schema.TypeBool,
+					Computed:    true,
+					Description: "Whether the domain is verified.",
+				},
+				methodKey: {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The verification method.",
+				},
+				ownersKey: {
+					Type:        schema.TypeList,
+					Computed:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "The emails of the owners of the verified domain.",
+				},
+				durationSecondsKey: {
+					Type:        schema.TypeFloat,
+					Computed:    true,
+					Description: "How long the verification took, retries included. 0 if the resource was imported.",
+				},
+			},
+		},
+		Description: "The outcome of the verification in a single object, e.g. to be exposed as an output.",
+	}
+}
+
+// setResult sets the result attribute of a verified domain. The duration of the verification is kept
+// from the previous result, unless a new one is given.
+func setResult(resourceData *schema.ResourceData, domain string, method string, owners []string, duration *time.Duration) error {
+	durationSeconds, _ := resourceData.Get(resultKey + ".0." + durationSecondsKey).(float64)
+	if duration != nil {
+		durationSeconds = duration.Seconds()
+	}
+
+	return resourceData.Set(resultKey, []interface{}{
+		map[string]interface{}{
+			idKey:              resourceData.Id(),
+			domainKey:          domain,
+			verifiedKey:        true,
+			methodKey:          method,
+			ownersKey:          owners,
+			durationSecondsKey: durationSeconds,
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadDnsSiteVerificationResult(t *testing.T) {
+	resourceData := readTestDnsSiteVerification(t, newTestProvider(t, verifiedHandler), nil)
+
+	result, ok := resourceData.Get(resultKey + ".0").(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a result, got %v", resourceData.Get(resultKey))
+	}
+	expected := map[string]interface{}{
+		idKey:              "dns://example.com",
+		domainKey:          "example.com",
+		verifiedKey:        true,
+		methodKey:          "DNS_TXT",
+		ownersKey:          []interface{}{"owner@example.com"},
+		durationSecondsKey: 0.0,
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected the result %v, got %v", expected, result)
+	}
+}
+
+func TestSetResultKeepsDuration(t *testing.T) {
+	resourceData := readTestDnsSiteVerification(t, newTestProvider(t, verifiedHandler), nil)
+
+	duration := 1500 * time.Millisecond
+	if setErr := setResult(resourceData, "example.com", "DNS_TXT", nil, &duration); setErr != nil {
+		t.Fatal(setErr)
+	}
+	if setErr := setResult(resourceData, "example.com", "DNS_TXT", []string{"owner@example.com"}, nil); setErr != nil {
+		t.Fatal(setErr)
+	}
+	if durationSeconds := resourceData.Get(resultKey + ".0." + durationSecondsKey).(float64); durationSeconds != 1.5 {
+		t.Errorf("expected the duration of the verification to be kept, got %g", durationSeconds)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+// retryProgressLogInterval is every how many attempts a retried verification or unverification logs its progress
+// at the INFO level rather than DEBUG.
+const retryProgressLogInterval = 10
+
+// pausePollInterval is how often a paused retry loop checks whether it can resume, a variable for the tests.
+var pausePollInterval = 5 * time.Second
+
+// transientErrorTimeout is how long the reads retry the API calls failing with a transient error.
+const transientErrorTimeout = 2 * time.Minute
+
+// transientStatusCodes are the HTTP status codes of the errors worth retrying whatever the call: rate limiting and server errors.
+var transientStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+}
+
+// defaultMaxRetryDelay caps the wait between two attempts when no other cap is configured, like the SDK's resource.Retry does.
+const defaultMaxRetryDelay = 10 * time.Second
+
+// backoff describes how long to wait between two attempts of a retried API call.
+type backoff struct {
+	baseDelay  time.Duration
+	multiplier float64
+	// maxDelay caps the wait between two attempts, defaultMaxRetryDelay if 0
+	maxDelay time.Duration
+	// jitter is the fraction by which each delay is randomly shortened or lengthened
+	jitter float64
+	// pauseFile, if not empty, is a file whose existence pauses the retries
+	pauseFile string
+	// disabled makes every retried call be attempted only once
+	disabled bool
+	// stop is done when Terraform is interrupted, which ends the waits between the attempts; nil if never
+	stop context.Context
+}
+
+// delay returns how long to wait after the given failed attempt (starting at 0).
+func (b backoff) delay(attempt int) time.Duration {
+	maxDelay := b.maxDelay
+	if maxDelay == 0 {
+		maxDelay = defaultMaxRetryDelay
+	}
+	delay := float64(b.baseDelay) * math.Pow(b.multiplier, float64(attempt))
+	if delay > float64(maxDelay) {
+		return maxDelay
+	}
+	return time.Duration(delay)
+}
+
+// jittered randomly shortens or lengthens the delay by up to the jitter fraction,
+// so that resources retrying at the same time spread their calls.
+func (b backoff) jittered(delay time.Duration) time.Duration {
+	return time.Duration(float64(delay) * (1 + b.jitter*(2*rand.Float64()-1)))
+}
+
+// retry calls f until it succeeds, returns a non-retryable error, or the timeout is reached.
+// It behaves like resource.Retry, but waits between attempts according to the backoff.
+func (b backoff) retry(timeout time.Duration, f resource.RetryFunc) error {
+	deadline := time.Now().Add(timeout)
+	for attempt := 0; ; attempt++ {
+		retryErr := f()
+		if retryErr == nil {
+			return nil
+		}
+		if !retryErr.Retryable || b.disabled {
+			return retryErr.Err
+		}
+
+		delay := b.jittered(b.delay(attempt))
+		if time.Now().Add(delay).After(deadline) {
+			return retryErr.Err
+		}
+		if !b.sleep(delay) || !b.waitWhilePaused(deadline) {
+			return retryErr.Err
+		}
+	}
+}
+
+// sleep waits for the delay, and returns false if Terraform was interrupted in the meantime.
+func (b backoff) sleep(delay time.Duration) bool {
+	if b.stop == nil {
+		time.Sleep(delay)
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return b.stop.Err() == nil
+	case <-b.stop.Done():
+		return false
+	}
+}
+
+// attemptLogLine describes a failed attempt of a retried verification or unverification in a greppable form, e.g.
+// [site-verification] create attempt=3 elapsed=90s remaining=3510s target=example.com error="..."
+func attemptLogLine(operation string, target string, attempt int, elapsed time.Duration, timeout time.Duration, err error) string {
+	remaining := timeout - elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	return fmt.Sprintf("[site-verification] %s attempt=%d elapsed=%ds remaining=%ds target=%s error=%q",
+		operation, attempt, int(elapsed.Seconds()), int(remaining.Seconds()), target, err.Error())
+}
+
+// logAttempt logs a failed attempt which is about to be retried, at the INFO level every retryProgressLogInterval attempts.
+func logAttempt(operation string, target string, attempt int, start time.Time, timeout time.Duration, err error) {
+	level := "DEBUG"
+	if attempt%retryProgressLogInterval == 0 {
+		level = "INFO"
+	}
+	log.Printf("[%s] %s", level, attemptLogLine(operation, target, attempt, time.Since(start), timeout, err))
+}
+
+// retryableAPICall calls f until it succeeds or fails with an error other than a transient one, for up to transientErrorTimeout.
+func (b backoff) retryableAPICall(description string, f func() error) error {
+	return b.retry(transientErrorTimeout, func() *resource.RetryError {
+		if err := f(); err != nil {
+			if transientStatusCodes[httpStatusCode(err)] {
+				log.Printf("[DEBUG] retrying %s after a transient error: %s", description, err)
+				return resource.RetryableError(err)
+			}
+			return resource.NonRetryableError(err)
+		}
+		return nil
+	})
+}
+
+// waitWhilePaused blocks while the pause file exists, up to the deadline.
+// It returns false if Terraform was interrupted in the meantime.
+func (b backoff) waitWhilePaused(deadline time.Time) bool {
+	if b.pauseFile == "" {
+		return true
+	}
+	for logged := false; time.Now().Before(deadline); logged = true {
+		if _, statErr := os.Stat(b.pauseFile); statErr != nil {
+			if logged {
+				log.Printf("[INFO] %s was removed, resuming the retries", b.pauseFile)
+			}
+			return true
+		}
+		if !logged {
+			log.Printf("[INFO] retries paused until %s is removed", b.pauseFile)
+		}
+		if !b.sleep(pausePollInterval) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateDuration(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	if _, parseErr := time.ParseDuration(v); parseErr != nil {
+		return nil, []error{fmt.Errorf("expected %s to be a duration such as \"2s\" or \"500ms\", got %q", k, v)}
+	}
+	return nil, nil
+}
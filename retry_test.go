@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"google.golang.org/api/googleapi"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	testCases := []struct {
+		name     string
+		backoff  backoff
+		expected []time.Duration
+	}{
+		{
+			name:    "default",
+			backoff: backoff{baseDelay: 500 * time.Millisecond, multiplier: 2},
+			expected: []time.Duration{
+				500 * time.Millisecond,
+				1 * time.Second,
+				2 * time.Second,
+				4 * time.Second,
+				8 * time.Second,
+				10 * time.Second,
+				10 * time.Second,
+			},
+		},
+		{
+			name:    "slow growth",
+			backoff: backoff{baseDelay: 2 * time.Second, multiplier: 1.5},
+			expected: []time.Duration{
+				2 * time.Second,
+				3 * time.Second,
+				4500 * time.Millisecond,
+				6750 * time.Millisecond,
+				10 * time.Second,
+			},
+		},
+		{
+			name:    "higher cap",
+			backoff: backoff{baseDelay: 10 * time.Second, multiplier: 3, maxDelay: time.Minute},
+			expected: []time.Duration{
+				10 * time.Second,
+				30 * time.Second,
+				time.Minute,
+				time.Minute,
+			},
+		},
+		{
+			name:    "constant",
+			backoff: backoff{baseDelay: time.Second, multiplier: 1},
+			expected: []time.Duration{
+				time.Second,
+				time.Second,
+				time.Second,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			for attempt, expected := range testCase.expected {
+				if actual := testCase.backoff.delay(attempt); actual != expected {
+					t.Errorf("attempt %d: expected a delay of %s, got %s", attempt, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffJittered(t *testing.T) {
+	const delay = 4 * time.Second
+
+	testCases := []struct {
+		jitter float64
+		min    time.Duration
+		max    time.Duration
+	}{
+		{jitter: 0, min: delay, max: delay},
+		{jitter: 0.1, min: 3600 * time.Millisecond, max: 4400 * time.Millisecond},
+		{jitter: 0.5, min: 2 * time.Second, max: 6 * time.Second},
+		{jitter: 1, min: 0, max: 8 * time.Second},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprint(testCase.jitter), func(t *testing.T) {
+			b := backoff{jitter: testCase.jitter}
+			for i := 0; i < 1000; i++ {
+				if actual := b.jittered(delay); actual < testCase.min || actual > testCase.max {
+					t.Fatalf("expected a delay between %s and %s, got %s", testCase.min, testCase.max, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryableAPICall(t *testing.T) {
+	b := backoff{baseDelay: time.Millisecond, multiplier: 1}
+
+	calls := 0
+	callErr := b.retryableAPICall("test", func() error {
+		calls++
+		if calls < 3 {
+			return &googleapi.Error{Code: http.StatusTooManyRequests, Message: "Rate Limit Exceeded"}
+		}
+		return nil
+	})
+	if callErr != nil || calls != 3 {
+		t.Errorf("expected the rate limited calls to be retried until they succeed, got %v after %d calls", callErr, calls)
+	}
+
+	calls = 0
+	callErr = b.retryableAPICall("test", func() error {
+		calls++
+		return &googleapi.Error{Code: http.StatusNotFound, Message: "Not Found"}
+	})
+	if httpStatusCode(callErr) != http.StatusNotFound || calls != 1 {
+		t.Errorf("expected a 404 not to be retried, got %v after %d calls", callErr, calls)
+	}
+
+	b.disabled = true
+	calls = 0
+	callErr = b.retryableAPICall("test", func() error {
+		calls++
+		return &googleapi.Error{Code: http.StatusTooManyRequests, Message: "Rate Limit Exceeded"}
+	})
+	if httpStatusCode(callErr) != http.StatusTooManyRequests || calls != 1 {
+		t.Errorf("expected the call to be attempted once with the retries disabled, got %v after %d calls", callErr, calls)
+	}
+}
+
+func TestRetryInterrupted(t *testing.T) {
+	stopContext, stop := context.WithCancel(context.Background())
+	b := backoff{baseDelay: time.Hour, multiplier: 1, maxDelay: time.Hour, stop: stopContext}
+	time.AfterFunc(10*time.Millisecond, stop)
+
+	start := time.Now()
+	retryErr := b.retry(2*time.Hour, func() *resource.RetryError {
+		return resource.RetryableError(errors.New("not yet"))
+	})
+	if retryErr == nil || retryErr.Error() != "not yet" {
+		t.Errorf("expected the last error once interrupted, got %v", retryErr)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("expected the wait to end when interrupted, it took %s", elapsed)
+	}
+}
+
+func TestRetryPauseFile(t *testing.T) {
+	defaultPollInterval := pausePollInterval
+	pausePollInterval = time.Millisecond
+	t.Cleanup(func() { pausePollInterval = defaultPollInterval })
+
+	pauseFile := filepath.Join(t.TempDir(), "pause")
+	if writeErr := os.WriteFile(pauseFile, nil, 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	b := backoff{baseDelay: time.Millisecond, multiplier: 1, pauseFile: pauseFile}
+
+	var calls int32
+	done := make(chan error)
+	go func() {
+		done <- b.retry(time.Minute, func() *resource.RetryError {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return resource.RetryableError(errors.New("not yet"))
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case retryErr := <-done:
+		t.Fatalf("expected the retries to be paused, got %v", retryErr)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if paused := atomic.LoadInt32(&calls); paused != 1 {
+		t.Errorf("expected no attempt while paused, got %d", paused)
+	}
+
+	if removeErr := os.Remove(pauseFile); removeErr != nil {
+		t.Fatal(removeErr)
+	}
+	select {
+	case retryErr := <-done:
+		if resumed := atomic.LoadInt32(&calls); retryErr != nil || resumed != 2 {
+			t.Errorf("expected the retries to resume and succeed, got %v after %d calls", retryErr, resumed)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("expected the retries to resume once the pause file is removed")
+	}
+}
+
+func TestRetryPauseFileInterrupted(t *testing.T) {
+	pauseFile := filepath.Join(t.TempDir(), "pause")
+	if writeErr := os.WriteFile(pauseFile, nil, 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	stopContext, stop := context.WithCancel(context.Background())
+	b := backoff{baseDelay: time.Millisecond, multiplier: 1, pauseFile: pauseFile, stop: stopContext}
+	time.AfterFunc(10*time.Millisecond, stop)
+
+	start := time.Now()
+	retryErr := b.retry(time.Hour, func() *resource.RetryError {
+		return resource.RetryableError(errors.New("not yet"))
+	})
+	if retryErr == nil || retryErr.Error() != "not yet" {
+		t.Errorf("expected the last error once interrupted, got %v", retryErr)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("expected a leftover pause file not to hold an interrupted run, it took %s", elapsed)
+	}
+}
+
+func TestAttemptLogLine(t *testing.T) {
+	line := attemptLogLine("create", "example.com", 3, 90*time.Second+400*time.Millisecond, time.Hour, fmt.Errorf("token not found"))
+	expected := `[site-verification] create attempt=3 elapsed=90s remaining=3509s target=example.com error="token not found"`
+	if line != expected {
+		t.Errorf("expected %q, got %q", expected, line)
+	}
+
+	line = attemptLogLine("delete", "dns://example.com", 1, 2*time.Minute, time.Minute, fmt.Errorf("still there"))
+	if expected := "remaining=0s"; !strings.Contains(line, expected) {
+		t.Errorf("expected %q in %q once the timeout is exceeded", expected, line)
+	}
+}
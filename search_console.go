@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+)
+
+const waitForSearchConsoleKey = "wait_for_search_console"
+const searchConsoleReadyKey = "search_console_ready"
+
+// searchConsoleTimeout bounds how long to wait for Search Console to know about a freshly verified domain.
+const searchConsoleTimeout = 5 * time.Minute
+
+var errSearchConsoleNotReady = errors.New("the property is not in Search Console yet")
+
+// searchConsoleReady reports whether Search Console knows the domain property and recognizes the caller as its owner.
+// Search Console being a separate API, a lack of permission to call it is not an error: the domain is just not ready.
+func searchConsoleReady(provider configuredProvider, domain string) (bool, error) {
+	ctx, cancel := provider.requestContext()
+	defer cancel()
+	site, getErr := provider.searchConsole.Sites.Get(fmt.Sprintf("sc-domain:%s", domain)).Context(ctx).Do()
+	if getErr != nil {
+		switch httpStatusCode(getErr) {
+		case http.StatusNotFound:
+			return false, nil
+		case http.StatusUnauthorized, http.StatusForbidden:
+			log.Printf("[WARN] not allowed to check %s in Search Console: %s", domain, getErr)
+			return false, nil
+		default:
+			return false, getErr
+		}
+	}
+	return site.PermissionLevel != "siteUnverifiedUser", nil
+}
+
+// waitForSearchConsole polls Search Console until it knows the domain property, for at most searchConsoleTimeout.
+// It never fails: the verification itself succeeded whatever Search Console says.
+func waitForSearchConsole(provider configuredProvider, domain string) {
+	retryErr := provider.backoff.retry(searchConsoleTimeout, func() *resource.RetryError {
+		ready, readyErr := searchConsoleReady(provider, domain)
+		if readyErr != nil {
+			return resource.NonRetryableError(readyErr)
+		}
+		if !ready {
+			return resource.RetryableError(errSearchConsoleNotReady)
+		}
+		return nil
+	})
+	if retryErr != nil {
+		log.Printf("[WARN] %s is verified, but not ready in Search Console: %s", domain, retryErr)
+	}
+}
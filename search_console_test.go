@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/webmasters/v3"
+)
+
+// newTestSearchConsole returns a Search Console client calling the handler.
+func newTestSearchConsole(t *testing.T, handler http.HandlerFunc) *webmasters.Service {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	service, serviceErr := webmasters.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	if serviceErr != nil {
+		t.Fatal(serviceErr)
+	}
+	return service
+}
+
+func TestSearchConsoleReady(t *testing.T) {
+	testCases := []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected bool
+		fails    bool
+	}{
+		{
+			name: "owner",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/sites/sc-domain:example.com" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(webmasters.WmxSite{SiteUrl: "sc-domain:example.com", PermissionLevel: "siteOwner"})
+			},
+			expected: true,
+		},
+		{
+			name: "unverified user",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(webmasters.WmxSite{SiteUrl: "sc-domain:example.com", PermissionLevel: "siteUnverifiedUser"})
+			},
+			expected: false,
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeAPIError(w, http.StatusNotFound, "Not Found")
+			},
+			expected: false,
+		},
+		{
+			name: "forbidden",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeAPIError(w, http.StatusForbidden, "Forbidden")
+			},
+			expected: false,
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeAPIError(w, http.StatusInternalServerError, "Internal Error")
+			},
+			fails: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("unexpected %s %s to the Site Verification API", r.Method, r.URL.Path)
+			})
+			provider.searchConsole = newTestSearchConsole(t, testCase.handler)
+
+			ready, readyErr := searchConsoleReady(provider, "example.com")
+			if testCase.fails {
+				if readyErr == nil {
+					t.Error("expected checking Search Console to fail")
+				}
+				return
+			}
+			if readyErr != nil {
+				t.Fatal(readyErr)
+			}
+			if ready != testCase.expected {
+				t.Errorf("expected %t, got %t", testCase.expected, ready)
+			}
+		})
+	}
+}
+
+func TestWaitForSearchConsole(t *testing.T) {
+	var calls int32
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s %s to the Site Verification API", r.Method, r.URL.Path)
+	})
+	provider.searchConsole = newTestSearchConsole(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			writeAPIError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(webmasters.WmxSite{SiteUrl: "sc-domain:example.com", PermissionLevel: "siteOwner"})
+	})
+
+	waitForSearchConsole(provider, "example.com")
+
+	if calls := atomic.LoadInt32(&calls); calls != 3 {
+		t.Errorf("expected Search Console to be polled until ready, 3 times, got %d", calls)
+	}
+}
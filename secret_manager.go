@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/secretmanager/v1"
+)
+
+// secretManagerPrefix starts the credentials which are references to a Secret Manager secret holding them,
+// e.g. sm://projects/my-project/secrets/site-verification-key/versions/latest.
+const secretManagerPrefix = "sm://"
+
+var secretVersionRegexp = regexp.MustCompile(`^projects/[^/]+/secrets/[^/]+(/versions/[^/]+)?$`)
+
+// secretManagerCredentialsJSON reads the credentials from the Secret Manager secret version the reference points to,
+// its latest version if none is given. The secret is read with the application default credentials, which are
+// only used for that: the credentials read from the secret authenticate the calls to the other APIs.
+// The options are added to the ones of the Secret Manager client.
+func secretManagerCredentialsJSON(ctx context.Context, reference string, options ...option.ClientOption) ([]byte, error) {
+	name := strings.TrimPrefix(reference, secretManagerPrefix)
+	if !secretVersionRegexp.MatchString(name) {
+		return nil, fmt.Errorf("expected a Secret Manager reference such as %sprojects/my-project/secrets/my-secret/versions/latest, got %q", secretManagerPrefix, reference)
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	service, serviceErr := secretmanager.NewService(ctx, append([]option.ClientOption{option.WithScopes(secretmanager.CloudPlatformScope)}, options...)...)
+	if serviceErr != nil {
+		return nil, fmt.Errorf("reading the credentials from %s with the application default credentials: %w", name, serviceErr)
+	}
+	version, accessErr := service.Projects.Secrets.Versions.Access(name).Context(ctx).Do()
+	if accessErr != nil {
+		return nil, fmt.Errorf("reading the credentials from %s with the application default credentials, which need the roles/secretmanager.secretAccessor role on the secret: %w", name, accessErr)
+	}
+	if version.Payload == nil {
+		return nil, fmt.Errorf("the secret version %s holds no credentials", name)
+	}
+	credentialsJSON, decodeErr := base64.StdEncoding.DecodeString(version.Payload.Data)
+	if decodeErr != nil {
+		return nil, fmt.Errorf("decoding the credentials read from %s: %w", name, decodeErr)
+	}
+	return credentialsJSON, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/api/option"
+)
+
+func TestSecretManagerCredentialsJSON(t *testing.T) {
+	credentialsJSON := `{"type": "service_account"}`
+	var accessed string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessed = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"name": "projects/p/secrets/s/versions/3", "payload": {"data": %q}}`, base64.StdEncoding.EncodeToString([]byte(credentialsJSON)))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		reference        string
+		expectedAccessed string
+	}{
+		{reference: "sm://projects/p/secrets/s/versions/3", expectedAccessed: "/v1/projects/p/secrets/s/versions/3:access"},
+		{reference: "sm://projects/p/secrets/s", expectedAccessed: "/v1/projects/p/secrets/s/versions/latest:access"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.reference, func(t *testing.T) {
+			read, readErr := secretManagerCredentialsJSON(context.Background(), testCase.reference, option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+			if readErr != nil {
+				t.Fatal(readErr)
+			}
+			if string(read) != credentialsJSON {
+				t.Errorf("expected the credentials held by the secret, got %q", read)
+			}
+			if accessed != testCase.expectedAccessed {
+				t.Errorf("expected %s to be accessed, got %s", testCase.expectedAccessed, accessed)
+			}
+		})
+	}
+
+	if _, readErr := secretManagerCredentialsJSON(context.Background(), "sm://my-secret"); readErr == nil {
+		t.Error("expected a reference without project to be refused")
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+const siteKey = "site"
+const metaTagKey = "meta_tag"
+const fileNameKey = "file_name"
+const fileContentKey = "file_content"
+
+// fileContentPrefix starts the content of the verification files, followed by their name.
+const fileContentPrefix = "google-site-verification: "
+
+// siteVerificationMethods are the verification methods of site URLs the provider supports.
+var siteVerificationMethods = []string{"META", "FILE", "ANALYTICS", "TAG_MANAGER"}
+
+func validateSiteURL(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	if _, canonicalErr := canonicalSiteURL(v); canonicalErr != nil {
+		return nil, []error{fmt.Errorf("%s: %w", k, canonicalErr)}
+	}
+	return nil, nil
+}
+
+func metaTokenDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			siteKey: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateSiteURL,
+				Description:  "The URL of the site you want to verify, e.g. `https://www.example.com/`.",
+			},
+			metaTagKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The meta tag you should add to the `<head>` of the site's home page, e.g. `<meta name=\"google-site-verification\" content=\"...\" />`.",
+			},
+		},
+		Description: "https://developers.google.com/site-verification/v1/webResource/getToken\n\nReturns the meta tag needed to verify a site URL with the `META` method.",
+		Read:        readMetaToken,
+	}
+}
+
+func readMetaToken(resourceData *schema.ResourceData, provider interface{}) error {
+	// the value has already been validated by the schema
+	site, _ := canonicalSiteURL(resourceData.Get(siteKey).(string))
+
+	token, getTokenErr := getToken(provider.(configuredProvider), site, "META")
+	if getTokenErr != nil {
+		return getTokenErr
+	}
+
+	if setErr := resourceData.Set(metaTagKey, token); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId(site)
+
+	return nil
+}
+
+// verificationFile returns the name and the content of the file to upload for a FILE token.
+// The token is the file name, but it is also accepted when it is already the whole content.
+func verificationFile(token string) (string, string, error) {
+	name := strings.TrimSpace(strings.TrimPrefix(token, fileContentPrefix))
+	if name == "" || strings.ContainsAny(name, "/ ") {
+		return "", "", fmt.Errorf("unexpected FILE token format %q", token)
+	}
+	return name, fileContentPrefix + name, nil
+}
+
+func fileTokenDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			siteKey: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateSiteURL,
+				Description:  "The URL of the site you want to verify, e.g. `https://www.example.com/`.",
+			},
+			fileNameKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the file you should upload at the root of the site, e.g. `google1234567890abcdef.html`. It is the whole name, extension included: use it as is, without adding any prefix or suffix.",
+			},
+			fileContentKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The content of the file you should upload, e.g. `google-site-verification: google1234567890abcdef.html`.",
+			},
+		},
+		Description: "https://developers.google.com/site-verification/v1/webResource/getToken\n\nReturns the file needed to verify a site URL with the `FILE` method.",
+		Read:        readFileToken,
+	}
+}
+
+func readFileToken(resourceData *schema.ResourceData, provider interface{}) error {
+	// the value has already been validated by the schema
+	site, _ := canonicalSiteURL(resourceData.Get(siteKey).(string))
+
+	token, getTokenErr := getToken(provider.(configuredProvider), site, "FILE")
+	if getTokenErr != nil {
+		return getTokenErr
+	}
+
+	name, content, fileErr := verificationFile(token)
+	if fileErr != nil {
+		return fileErr
+	}
+
+	if setErr := resourceData.Set(fileNameKey, name); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(fileContentKey, content); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId(site)
+
+	return nil
+}
+
+func siteResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			siteKey: {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateFunc:     validateSiteURL,
+				DiffSuppressFunc: suppressEquivalentSiteURL,
+				Description:      "The URL of the site you want to verify, e.g. `https://www.example.com/`. Differences in scheme and host casing, default ports and trailing slashes are ignored.",
+			},
+			methodKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "META",
+				ValidateFunc: validation.StringInSlice(siteVerificationMethods, false),
+				Description:  "The verification method: `META`, with the tag from data.googlesiteverification_meta_token on the site's home page, `FILE`, with the file from data.googlesiteverification_file_token at the root of the site, or `ANALYTICS` and `TAG_MANAGER`, with the Google Analytics tracking code or the Google Tag Manager container snippet already on the site's home page, which the credentials must have edit access to.",
+			},
+			ownersKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The emails of the owners of the verified site.",
+			},
+		},
+		Create:      createSiteVerification,
+		Read:        readSiteVerification,
+		Delete:      deleteSiteVerificationResource,
+		Description: "https://developers.google.com/site-verification\n\nVerifies a site URL, rather than a whole domain. The token must already be in place on the site.",
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+func createSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	// the value has already been validated by the schema
+	site, _ := canonicalSiteURL(resourceData.Get(siteKey).(string))
+
+	id, insertErr := insertSiteVerification(provider.(configuredProvider), resourceData.Timeout(schema.TimeoutCreate), site, resourceData.Get(methodKey).(string))
+	if insertErr != nil {
+		return insertErr
+	}
+	resourceData.SetId(id)
+
+	return readSiteVerification(resourceData, provider)
+}
+
+func readSiteVerification(resourceData *schema.ResourceData, provider interface{}) error {
+	webResource, getErr := getWebResource(provider.(configuredProvider), resourceData.Id())
+	if getErr != nil {
+		if httpStatusCode(getErr) == http.StatusNotFound {
+			// unverified out of band: Terraform will plan to verify it again
+			log.Printf("[WARN] %s is not verified anymore, removing it from the state", resourceData.Id())
+			resourceData.SetId("")
+			return nil
+		}
+		return getErr
+	}
+
+	if webResource.Site != nil {
+		if setErr := resourceData.Set(siteKey, webResource.Site.Identifier); setErr != nil {
+			return setErr
+		}
+	}
+	if _, ok := resourceData.GetOk(methodKey); !ok {
+		// imported: the API does not tell which method a resource was verified with
+		if setErr := resourceData.Set(methodKey, "META"); setErr != nil {
+			return setErr
+		}
+	}
+	return resourceData.Set(ownersKey, webResource.Owners)
+}
+
+func deleteSiteVerificationResource(resourceData *schema.ResourceData, provider interface{}) error {
+	return deleteSiteVerification(provider.(configuredProvider), resourceData.Timeout(schema.TimeoutDelete), resourceData.Id())
+}
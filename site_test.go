@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestVerificationFile(t *testing.T) {
+	for _, token := range []string{
+		"google1234567890abcdef.html",
+		"google-site-verification: google1234567890abcdef.html",
+	} {
+		name, content, fileErr := verificationFile(token)
+		if fileErr != nil {
+			t.Fatal(fileErr)
+		}
+		if name != "google1234567890abcdef.html" {
+			t.Errorf("%q: expected the name google1234567890abcdef.html, got %q", token, name)
+		}
+		if content != "google-site-verification: google1234567890abcdef.html" {
+			t.Errorf("%q: expected the content google-site-verification: google1234567890abcdef.html, got %q", token, content)
+		}
+	}
+
+	for _, token := range []string{"", "google-site-verification: ", "path/to/google123.html"} {
+		if _, _, fileErr := verificationFile(token); fileErr == nil {
+			t.Errorf("expected %q to be rejected", token)
+		}
+	}
+}
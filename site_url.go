@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// defaultPorts are the ports a SITE URL does not need to mention, by scheme.
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// canonicalSiteURL returns the form Google stores the identifier of a SITE web resource in:
+// lowercase scheme and host, no default port, and a path ending with a slash,
+// e.g. "https://Example.com:443" becomes "https://example.com/".
+// A query or a fragment is refused, as Google would verify a different URL than the one configured.
+func canonicalSiteURL(siteURL string) (string, error) {
+	parsed, parseErr := url.Parse(siteURL)
+	if parseErr != nil {
+		return "", parseErr
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("expected an absolute URL such as https://www.example.com/, got %q", siteURL)
+	}
+
+	if parsed.RawQuery != "" || parsed.ForceQuery || parsed.Fragment != "" {
+		return "", fmt.Errorf("expected a URL without a query or a fragment, got %q", siteURL)
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if _, ok := defaultPorts[scheme]; !ok {
+		return "", fmt.Errorf("expected an http or https URL, got %q", siteURL)
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if port := parsed.Port(); port != "" && port != defaultPorts[scheme] {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// an IPv6 address keeps its brackets
+		host = fmt.Sprintf("[%s]", host)
+	}
+
+	path := parsed.EscapedPath()
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, host, path), nil
+}
+
+// suppressEquivalentSiteURL is a DiffSuppressFunc ignoring the cosmetic differences between two SITE URLs.
+func suppressEquivalentSiteURL(k string, old string, new string, d *schema.ResourceData) bool {
+	canonicalOld, oldErr := canonicalSiteURL(old)
+	canonicalNew, newErr := canonicalSiteURL(new)
+	return oldErr == nil && newErr == nil && canonicalOld == canonicalNew
+}
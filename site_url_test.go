@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestCanonicalSiteURL(t *testing.T) {
+	testCases := []struct {
+		siteURL  string
+		expected string
+	}{
+		{siteURL: "https://example.com/", expected: "https://example.com/"},
+		{siteURL: "https://Example.com:443/", expected: "https://example.com/"},
+		{siteURL: "HTTPS://EXAMPLE.COM", expected: "https://example.com/"},
+		{siteURL: "http://example.com:80/blog", expected: "http://example.com/blog/"},
+		{siteURL: "http://example.com:443/", expected: "http://example.com:443/"},
+		{siteURL: "https://example.com:8443/Blog/", expected: "https://example.com:8443/Blog/"},
+		{siteURL: "https://[2001:DB8::1]/", expected: "https://[2001:db8::1]/"},
+		{siteURL: "https://[2001:db8::1]:443", expected: "https://[2001:db8::1]/"},
+		{siteURL: "https://[2001:db8::1]:8443/", expected: "https://[2001:db8::1]:8443/"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.siteURL, func(t *testing.T) {
+			actual, canonicalErr := canonicalSiteURL(testCase.siteURL)
+			if canonicalErr != nil {
+				t.Fatal(canonicalErr)
+			}
+			if actual != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, actual)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"example.com", "ftp://example.com/", "://", "https://example.com/?page=1", "https://example.com/?", "https://example.com/#top"} {
+		if _, canonicalErr := canonicalSiteURL(invalid); canonicalErr == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestSuppressEquivalentSiteURL(t *testing.T) {
+	if !suppressEquivalentSiteURL("site", "https://example.com/", "https://Example.com:443/", nil) {
+		t.Error("expected https://Example.com:443/ and https://example.com/ to be equivalent")
+	}
+	if suppressEquivalentSiteURL("site", "https://example.com/", "http://example.com/", nil) {
+		t.Error("expected http://example.com/ and https://example.com/ to differ")
+	}
+}
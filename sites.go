@@ -0,0 +1,79 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const sitesKey = "sites"
+const identifierKey = "identifier"
+
+func sitesDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			sitesKey: {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						idKey: {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The id of the web resource, e.g. `dns://example.com`.",
+						},
+						identifierKey: {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The domain or the site URL.",
+						},
+						typeKey: {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The type of the web resource: `INET_DOMAIN` or `SITE`.",
+						},
+						ownersKey: {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "The emails of the owners of the web resource.",
+						},
+					},
+				},
+				Description: "The verified web resources, sorted by id.",
+			},
+		},
+		Description: "https://developers.google.com/site-verification/v1/webResource/list\n\nLists all the web resources the credentials are an owner of, domains and site URLs alike, e.g. to find the verifications Terraform doesn't manage.",
+		Read:        readSites,
+	}
+}
+
+func readSites(resourceData *schema.ResourceData, provider interface{}) error {
+	list, listErr := listWebResources(provider.(configuredProvider))
+	if listErr != nil {
+		return listErr
+	}
+
+	sites := make([]map[string]interface{}, 0, len(list.Items))
+	for _, item := range list.Items {
+		site := map[string]interface{}{
+			idKey:     item.Id,
+			ownersKey: item.Owners,
+		}
+		if item.Site != nil {
+			site[identifierKey] = item.Site.Identifier
+			site[typeKey] = item.Site.Type
+		}
+		sites = append(sites, site)
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		return sites[i][idKey].(string) < sites[j][idKey].(string)
+	})
+
+	if setErr := resourceData.Set(sitesKey, sites); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId("sites")
+
+	return nil
+}
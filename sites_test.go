@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestReadSites(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"items": [
+			{"id": "https%3A%2F%2Fwww.example.com%2F", "owners": ["owner@example.com"], "site": {"identifier": "https://www.example.com/", "type": "SITE"}},
+			{"id": "dns%3A%2F%2Fexample.com", "owners": ["owner@example.com", "other@example.com"], "site": {"identifier": "example.com", "type": "INET_DOMAIN"}}
+		]}`)
+	})
+
+	resourceData := schema.TestResourceDataRaw(t, sitesDataSource().Schema, map[string]interface{}{})
+	if readErr := readSites(resourceData, provider); readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{idKey: "dns%3A%2F%2Fexample.com", identifierKey: "example.com", typeKey: "INET_DOMAIN", ownersKey: []interface{}{"owner@example.com", "other@example.com"}},
+		map[string]interface{}{idKey: "https%3A%2F%2Fwww.example.com%2F", identifierKey: "https://www.example.com/", typeKey: "SITE", ownersKey: []interface{}{"owner@example.com"}},
+	}
+	if sites := resourceData.Get(sitesKey); !reflect.DeepEqual(sites, expected) {
+		t.Errorf("expected the sites sorted by id %v, got %v", expected, sites)
+	}
+}
+
+func TestReadSitesForbidden(t *testing.T) {
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusForbidden, "Forbidden")
+	})
+
+	resourceData := schema.TestResourceDataRaw(t, sitesDataSource().Schema, map[string]interface{}{})
+	if readErr := readSites(resourceData, provider); httpStatusCode(readErr) != http.StatusForbidden {
+		t.Errorf("expected the listing to fail with 403, got %v", readErr)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func statusDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			domainKey: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The domain to check, e.g. `example.com`.",
+			},
+			verifiedKey: {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the domain is verified by the provider's credentials.",
+			},
+			ownersKey: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The emails of the owners of the domain. Empty if it is not verified.",
+			},
+		},
+		Description: "https://developers.google.com/site-verification/v1/webResource/get\n\nTells whether a domain is verified, without managing its verification. Unlike data.googlesiteverification_dns, an unverified domain is not an error, e.g. to verify a domain only if it isn't already.",
+		Read:        readStatus,
+	}
+}
+
+func readStatus(resourceData *schema.ResourceData, provider interface{}) error {
+	id := webResourceID(bareDomain(resourceData.Get(domainKey).(string)))
+
+	verified := true
+	owners := []string{}
+	webResource, getErr := getWebResource(provider.(configuredProvider), id)
+	if getErr != nil {
+		if httpStatusCode(getErr) != http.StatusNotFound {
+			return getErr
+		}
+		verified = false
+	} else if webResource.Owners != nil {
+		owners = webResource.Owners
+	}
+
+	if setErr := resourceData.Set(verifiedKey, verified); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(ownersKey, owners); setErr != nil {
+		return setErr
+	}
+	resourceData.SetId(id)
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestReadStatus(t *testing.T) {
+	verified := true
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/webResource/dns://example.com" {
+			t.Errorf("expected the web resource of example.com to be read, got %s", r.URL.Path)
+		}
+		if !verified {
+			writeAPIError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id": "dns%3A%2F%2Fexample.com", "owners": ["a@example.com"]}`)
+	})
+
+	for _, domain := range []string{"example.com", "Example.com", "sc-domain:example.com"} {
+		t.Run(domain, func(t *testing.T) {
+			verified = true
+			resourceData := schema.TestResourceDataRaw(t, statusDataSource().Schema, map[string]interface{}{domainKey: domain})
+			if readErr := readStatus(resourceData, provider); readErr != nil {
+				t.Fatal(readErr)
+			}
+			if id := resourceData.Id(); id != "dns://example.com" {
+				t.Errorf("expected the id dns://example.com, got %s", id)
+			}
+			if !resourceData.Get(verifiedKey).(bool) || len(resourceData.Get(ownersKey).([]interface{})) != 1 {
+				t.Errorf("expected example.com to be verified with its owner, got %v %v", resourceData.Get(verifiedKey), resourceData.Get(ownersKey))
+			}
+
+			verified = false
+			if readErr := readStatus(resourceData, provider); readErr != nil {
+				t.Fatal(readErr)
+			}
+			if resourceData.Get(verifiedKey).(bool) {
+				t.Error("expected an unverified domain not to be an error, but verified = false")
+			}
+		})
+	}
+}
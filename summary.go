@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const attemptedKey = "attempted"
+const succeededKey = "succeeded"
+const failedKey = "failed"
+const averageDurationSecondsKey = "average_duration_seconds"
+const reportKey = "report"
+const reportJSONKey = "report_json"
+
+// summaryReport is the JSON form of the summary report.
+type summaryReport struct {
+	Attempted              int               `json:"attempted"`
+	Succeeded              int               `json:"succeeded"`
+	Failed                 int               `json:"failed"`
+	Failures               map[string]string `json:"failures"`
+	AverageDurationSeconds float64           `json:"average_duration_seconds"`
+}
+
+func summaryDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			verifiedMethodsKey: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The verified domains, mapped to their method: the `verified_methods` of a `googlesiteverification_dns_domains` resource, or several of them merged.",
+			},
+			failedDomainsKey: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains which failed, mapped to the error: the `failed_domains` of a `googlesiteverification_dns_domains` resource, or several of them merged.",
+			},
+			domainStatusKey: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The domains mapped to `VERIFIED` or to their error: the `domain_status` of a `googlesiteverification_dns_batch` resource, or several of them merged. Summarized along with `verified_methods` and `failed_domains`.",
+			},
+			pathKey: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The path of a file to write the human-readable report to. Not written if not provided.",
+			},
+			attemptedKey: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "How many domains were attempted.",
+			},
+			succeededKey: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "How many domains are verified.",
+			},
+			failedKey: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "How many domains failed.",
+			},
+			averageDurationSecondsKey: {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "The average time the verification of the verified domains took, retries included, according to the provider's `metrics_file`. 0 when the provider has no `metrics_file` or it knows none of the domains.",
+			},
+			reportKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The human-readable report.",
+			},
+			reportJSONKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The report in JSON, with the `attempted`, `succeeded` and `failed` counts, the `failures` mapped to their error, and the `average_duration_seconds`.",
+			},
+		},
+		Description: "Summarizes the outcome of batch verifications in a single report. Terraform has no hook to run at the end of an apply, so this reads the outcome from the attributes of the `googlesiteverification_dns_domains` and `googlesiteverification_dns_batch` resources it is given, which makes it run after them.",
+		Read:        readSummary,
+	}
+}
+
+func readSummary(resourceData *schema.ResourceData, provider interface{}) error {
+	verified := map[string]interface{}{}
+	for domain := range resourceData.Get(verifiedMethodsKey).(map[string]interface{}) {
+		verified[domain] = true
+	}
+	report := summaryReport{Failures: map[string]string{}}
+	for domain, failure := range resourceData.Get(failedDomainsKey).(map[string]interface{}) {
+		report.Failures[domain] = failure.(string)
+	}
+	// the statuses of the batches are either VERIFIED or the error their domain failed with
+	for domain, status := range resourceData.Get(domainStatusKey).(map[string]interface{}) {
+		if status == verifiedStatus {
+			verified[domain] = true
+		} else {
+			report.Failures[domain] = status.(string)
+		}
+	}
+	report.Succeeded = len(verified)
+	report.Failed = len(report.Failures)
+	report.Attempted = report.Succeeded + report.Failed
+
+	averageDuration, averageErr := averageVerificationSeconds(provider.(configuredProvider).metrics, sortedKeys(verified))
+	if averageErr != nil {
+		return averageErr
+	}
+	report.AverageDurationSeconds = averageDuration
+
+	reportJSON, marshalErr := json.Marshal(report)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	text := report.String()
+
+	if path := resourceData.Get(pathKey).(string); path != "" {
+		if writeErr := os.WriteFile(path, []byte(text), 0644); writeErr != nil {
+			return fmt.Errorf("writing the report: %w", writeErr)
+		}
+	}
+
+	if setErr := resourceData.Set(attemptedKey, report.Attempted); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(succeededKey, report.Succeeded); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(failedKey, report.Failed); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(averageDurationSecondsKey, report.AverageDurationSeconds); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(reportKey, text); setErr != nil {
+		return setErr
+	}
+	if setErr := resourceData.Set(reportJSONKey, string(reportJSON)); setErr != nil {
+		return setErr
+	}
+
+	resourceData.SetId(strconv.Itoa(hashcode.String(string(reportJSON))))
+
+	return nil
+}
+
+// String renders the report for humans.
+func (report summaryReport) String() string {
+	var text strings.Builder
+	_, _ = fmt.Fprintf(&text, "%d domain(s) attempted: %d verified, %d failed.\n", report.Attempted, report.Succeeded, report.Failed)
+	if report.AverageDurationSeconds > 0 {
+		_, _ = fmt.Fprintf(&text, "Average verification time: %.1fs.\n", report.AverageDurationSeconds)
+	}
+	if report.Failed > 0 {
+		domains := make([]string, 0, len(report.Failures))
+		for domain := range report.Failures {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		text.WriteString("\nFailures:\n")
+		for _, domain := range domains {
+			_, _ = fmt.Fprintf(&text, "  %s: %s\n", domain, report.Failures[domain])
+		}
+	}
+	return text.String()
+}
+
+// averageVerificationSeconds returns the average duration of the last verification of the domains known to the metrics file,
+// or 0 if there is no metrics file or it knows none of the domains.
+func averageVerificationSeconds(recorder *metricsRecorder, domains []string) (float64, error) {
+	if recorder == nil {
+		return 0, nil
+	}
+	samples, readErr := readMetricsSamples(recorder.path)
+	if readErr != nil {
+		return 0, fmt.Errorf("reading the metrics file %s: %w", recorder.path, readErr)
+	}
+
+	total, count := 0.0, 0
+	for _, domain := range domains {
+		labels := fmt.Sprintf(`operation="create",domain="%s"`, labelValueEscaper.Replace(domain))
+		if duration, ok := samples[labels][metricsPrefix+"duration_seconds"]; ok {
+			total += duration
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	return total / float64(count), nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestReadSummary(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   map[string]interface{}
+		expected summaryReport
+	}{
+		{
+			name: "dns_domains",
+			config: map[string]interface{}{
+				verifiedMethodsKey: map[string]interface{}{"a.example.com": "DNS_TXT"},
+				failedDomainsKey:   map[string]interface{}{"b.example.com": "Forbidden"},
+			},
+			expected: summaryReport{Attempted: 2, Succeeded: 1, Failed: 1},
+		},
+		{
+			name: "dns_batch",
+			config: map[string]interface{}{
+				domainStatusKey: map[string]interface{}{"a.example.com": "VERIFIED", "c.example.com": "VERIFIED", "b.example.com": "Forbidden"},
+			},
+			expected: summaryReport{Attempted: 3, Succeeded: 2, Failed: 1},
+		},
+		{
+			name: "both",
+			config: map[string]interface{}{
+				verifiedMethodsKey: map[string]interface{}{"a.example.com": "DNS_TXT"},
+				domainStatusKey:    map[string]interface{}{"a.example.com": "VERIFIED", "b.example.com": "Forbidden"},
+			},
+			expected: summaryReport{Attempted: 2, Succeeded: 1, Failed: 1},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resourceData := schema.TestResourceDataRaw(t, summaryDataSource().Schema, testCase.config)
+			if readErr := readSummary(resourceData, configuredProvider{}); readErr != nil {
+				t.Fatal(readErr)
+			}
+			actual := summaryReport{
+				Attempted: resourceData.Get(attemptedKey).(int),
+				Succeeded: resourceData.Get(succeededKey).(int),
+				Failed:    resourceData.Get(failedKey).(int),
+			}
+			if actual.Attempted != testCase.expected.Attempted || actual.Succeeded != testCase.expected.Succeeded || actual.Failed != testCase.expected.Failed {
+				t.Errorf("expected %d attempted, %d verified and %d failed, got %d, %d and %d",
+					testCase.expected.Attempted, testCase.expected.Succeeded, testCase.expected.Failed, actual.Attempted, actual.Succeeded, actual.Failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+)
+
+// testDomainSuffix ends the domains the acceptance tests verify, which the sweeper unverifies.
+const testDomainSuffix = "-test-terraform-provider.hectorj.net"
+
+// sweepTimeout is how long the sweeper waits for Google to see that the token of a domain was removed.
+const sweepTimeout = time.Minute
+
+func init() {
+	resource.AddTestSweepers("googlesiteverification_dns", &resource.Sweeper{
+		Name: "googlesiteverification_dns",
+		F:    sweepDnsSiteVerifications,
+	})
+}
+
+func TestMain(m *testing.M) {
+	resource.TestMain(m)
+}
+
+// sweepDnsSiteVerifications unverifies the domains left behind by the acceptance tests.
+// Run it with `go test -sweep=global`: the region is meaningless for this API.
+func sweepDnsSiteVerifications(_ string) error {
+	rawProvider := Provider().(*schema.Provider)
+	if configureErr := rawProvider.Configure(terraform.NewResourceConfigRaw(map[string]interface{}{})); configureErr != nil {
+		return configureErr
+	}
+	provider := rawProvider.Meta().(configuredProvider)
+
+	list, listErr := provider.service.WebResource.List().Do()
+	if listErr != nil {
+		return listErr
+	}
+
+	var sweepErr *multierror.Error
+	for _, item := range list.Items {
+		if item.Site == nil || item.Site.Type != siteType || !strings.HasSuffix(item.Site.Identifier, testDomainSuffix) {
+			continue
+		}
+		id, unescapeErr := url.QueryUnescape(item.Id)
+		if unescapeErr != nil {
+			sweepErr = multierror.Append(sweepErr, unescapeErr)
+			continue
+		}
+		log.Printf("[INFO] sweeping %s", id)
+		if deleteErr := deleteSiteVerification(provider, sweepTimeout, id); deleteErr != nil {
+			sweepErr = multierror.Append(sweepErr, deleteErr)
+		}
+	}
+	return sweepErr.ErrorOrNil()
+}
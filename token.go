@@ -0,0 +1,130 @@
+package main
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+const snippetKey = "snippet"
+
+func tokenDataSource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			identifierKey: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "What you want to verify: a bare domain such as `example.com` for `INET_DOMAIN`, or a URL such as `https://www.example.com/` for `SITE`.",
+			},
+			methodKey: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice(verificationMethods, false),
+				Description:  "The verification method you want a token for: `DNS_TXT` or `DNS_CNAME` for domains, `META`, `FILE`, `ANALYTICS` or `TAG_MANAGER` for sites.",
+			},
+			typeKey: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(webResourceTypes, false),
+				Description:  "The type of web resource to verify: `INET_DOMAIN`, `SITE` or `ANDROID_APP`. Defaults to `INET_DOMAIN` for the DNS methods, and `SITE` for the others.",
+			},
+			tokenKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The token as Google returns it.",
+			},
+			recordTypeKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The type of DNS record you should create: `TXT` or `CNAME`. Only set for the DNS methods.",
+			},
+			recordNameKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the DNS record you should create. Only set for the DNS methods.",
+			},
+			recordValueKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The value of the DNS record you should create. Only set for the DNS methods.",
+			},
+			metaTagKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The meta tag you should add to the `<head>` of the site's home page. Only set for the `META` method.",
+			},
+			fileNameKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the file you should upload at the root of the site. Only set for the `FILE` method.",
+			},
+			fileContentKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The content of the file you should upload. Only set for the `FILE` method.",
+			},
+			snippetKey: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The Google Analytics tracking code or Google Tag Manager container snippet the site's home page should hold. Only set for the `ANALYTICS` and `TAG_MANAGER` methods.",
+			},
+		},
+		Description: "https://developers.google.com/site-verification/v1/webResource/getToken\n\nReturns the token of any verification method, along with what to do with it: the DNS record to create, the meta tag to add, the file to upload or the analytics snippet to have. data.googlesiteverification_dns_token, data.googlesiteverification_meta_token and data.googlesiteverification_file_token remain for the methods they cover.",
+		Read:        readToken,
+	}
+}
+
+func readToken(resourceData *schema.ResourceData, provider interface{}) error {
+	identifier := resourceData.Get(identifierKey).(string)
+	method := resourceData.Get(methodKey).(string)
+	webResourceType := siteTypeOf(method)
+	if configuredType, ok := resourceData.GetOk(typeKey); ok {
+		webResourceType = configuredType.(string)
+	}
+	if typeErr := checkWebResourceType(webResourceType, method); typeErr != nil {
+		return typeErr
+	}
+	if identifierErr := checkIdentifier(webResourceType, identifier); identifierErr != nil {
+		return identifierErr
+	}
+	if webResourceType == urlSiteType {
+		// the value has just been checked
+		identifier, _ = canonicalSiteURL(identifier)
+	}
+
+	token, getTokenErr := getTokenOfType(provider.(configuredProvider), webResourceType, identifier, method)
+	if getTokenErr != nil {
+		return getTokenErr
+	}
+
+	fields := map[string]string{tokenKey: token}
+	switch method {
+	case "DNS_TXT", "DNS_CNAME":
+		record, recordErr := dnsRecordFromToken(identifier, method, token)
+		if recordErr != nil {
+			return recordErr
+		}
+		fields[recordTypeKey] = record.recordType
+		fields[recordNameKey] = record.name
+		fields[recordValueKey] = record.value
+	case "META":
+		fields[metaTagKey] = token
+	case "FILE":
+		name, content, fileErr := verificationFile(token)
+		if fileErr != nil {
+			return fileErr
+		}
+		fields[fileNameKey] = name
+		fields[fileContentKey] = content
+	case "ANALYTICS", "TAG_MANAGER":
+		fields[snippetKey] = token
+	}
+
+	for key, value := range fields {
+		if setErr := resourceData.Set(key, value); setErr != nil {
+			return setErr
+		}
+	}
+	resourceData.SetId(identifier)
+
+	return nil
+}
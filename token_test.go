@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"google.golang.org/api/siteverification/v1"
+)
+
+func TestReadToken(t *testing.T) {
+	tokens := map[string]string{
+		"DNS_TXT":     "google-site-verification=abc",
+		"META":        `<meta name="google-site-verification" content="abc" />`,
+		"FILE":        "google1234567890abcdef.html",
+		"TAG_MANAGER": "GTM-ABC123",
+	}
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var request siteverification.SiteVerificationWebResourceGettokenRequest
+		if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+			t.Fatal(decodeErr)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"method": %q, "token": %q}`, request.VerificationMethod, tokens[request.VerificationMethod])
+	})
+
+	testCases := []struct {
+		identifier string
+		method     string
+		expected   map[string]string
+	}{
+		{identifier: "example.com", method: "DNS_TXT", expected: map[string]string{recordTypeKey: "TXT", recordNameKey: "example.com", recordValueKey: tokens["DNS_TXT"], metaTagKey: ""}},
+		{identifier: "https://www.example.com", method: "META", expected: map[string]string{metaTagKey: tokens["META"], recordTypeKey: ""}},
+		{identifier: "https://www.example.com/", method: "FILE", expected: map[string]string{fileNameKey: tokens["FILE"], fileContentKey: "google-site-verification: " + tokens["FILE"]}},
+		{identifier: "https://www.example.com/", method: "TAG_MANAGER", expected: map[string]string{snippetKey: tokens["TAG_MANAGER"], fileNameKey: ""}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.method, func(t *testing.T) {
+			resourceData := schema.TestResourceDataRaw(t, tokenDataSource().Schema, map[string]interface{}{
+				identifierKey: testCase.identifier,
+				methodKey:     testCase.method,
+			})
+			if readErr := readToken(resourceData, provider); readErr != nil {
+				t.Fatal(readErr)
+			}
+			for key, expected := range testCase.expected {
+				if actual := resourceData.Get(key).(string); actual != expected {
+					t.Errorf("expected %s to be %q, got %q", key, expected, actual)
+				}
+			}
+		})
+	}
+
+	resourceData := schema.TestResourceDataRaw(t, tokenDataSource().Schema, map[string]interface{}{
+		identifierKey: "example.com",
+		methodKey:     "DNS_TXT",
+		typeKey:       "SITE",
+	})
+	if readErr := readToken(resourceData, provider); readErr == nil {
+		t.Error("expected a DNS method to be refused for a SITE")
+	}
+}
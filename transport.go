@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"golang.org/x/net/http/httpproxy"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+const proxyURLKey = "proxy_url"
+const localAddressKey = "local_address"
+const caBundleKey = "ca_bundle"
+const insecureSkipVerifyKey = "insecure_skip_verify"
+const requestTimeoutKey = "request_timeout"
+const maxConcurrentRequestsKey = "max_concurrent_requests"
+
+// baseTransport returns the transport to send the API calls through, customized according to the
+// provider's network settings. The returned boolean is false if there was nothing to customize.
+func baseTransport(resourceData *schema.ResourceData) (*http.Transport, bool, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	customized := false
+
+	if proxyURL, ok := resourceData.GetOk(proxyURLKey); ok {
+		transport.Proxy = proxyFunc(proxyURL.(string), noProxyFromEnvironment())
+		customized = true
+	}
+	if localAddress, ok := resourceData.GetOk(localAddressKey); ok {
+		transport.DialContext = newDialer(localAddress.(string)).DialContext
+		customized = true
+	}
+	if caBundle, ok := resourceData.GetOk(caBundleKey); ok {
+		rootCAs, caBundleErr := loadCABundle(caBundle.(string))
+		if caBundleErr != nil {
+			return nil, false, caBundleErr
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+		customized = true
+	}
+	if resourceData.Get(insecureSkipVerifyKey).(bool) {
+		log.Printf("[WARN] %s is enabled: the TLS certificates of the API are NOT verified, anybody on the network path can intercept the calls and the credentials", insecureSkipVerifyKey)
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		customized = true
+	}
+
+	return transport, customized, nil
+}
+
+// throttledTransport lets at most as many requests as its semaphore holds be sent at once, the others waiting for their turn.
+// The resources and data sources of a provider share its transport, so this bounds the calls Terraform makes concurrently.
+type throttledTransport struct {
+	base      http.RoundTripper
+	semaphore chan struct{}
+}
+
+func newThrottledTransport(base http.RoundTripper, maxConcurrentRequests int) throttledTransport {
+	return throttledTransport{base: base, semaphore: make(chan struct{}, maxConcurrentRequests)}
+}
+
+func (transport throttledTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	select {
+	case transport.semaphore <- struct{}{}:
+	case <-request.Context().Done():
+		return nil, request.Context().Err()
+	}
+	defer func() { <-transport.semaphore }()
+
+	return transport.base.RoundTrip(request)
+}
+
+// loadCABundle parses a PEM bundle of CA certificates, given either as a path or as its content.
+func loadCABundle(caBundle string) (*x509.CertPool, error) {
+	pem := []byte(caBundle)
+	if !strings.Contains(caBundle, "-----BEGIN") {
+		var readErr error
+		pem, readErr = os.ReadFile(caBundle)
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s does not hold any PEM encoded certificate", caBundleKey)
+	}
+	return rootCAs, nil
+}
+
+// newHTTPClient returns an authenticated HTTP client sending its requests through the given base transport.
+func newHTTPClient(ctx context.Context, base http.RoundTripper, authClientOptions ...option.ClientOption) (*http.Client, error) {
+	transport, transportErr := htransport.NewTransport(ctx, base, append(authClientOptions, option.WithScopes(oauthScopes...))...)
+	if transportErr != nil {
+		return nil, transportErr
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// proxyFunc sends requests through proxyURL, except for the hosts matched by noProxy
+// (which follows the same format as the NO_PROXY environment variable).
+func proxyFunc(proxyURL string, noProxy string) func(*http.Request) (*url.URL, error) {
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    noProxy,
+	}).ProxyFunc()
+
+	return func(request *http.Request) (*url.URL, error) {
+		return proxy(request.URL)
+	}
+}
+
+// newDialer returns a dialer opening its connections from the given local IP address.
+// Its other settings are the same as http.DefaultTransport's.
+func newDialer(localAddress string) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		LocalAddr: &net.TCPAddr{IP: net.ParseIP(localAddress)},
+	}
+}
+
+// noProxyFromEnvironment reads the NO_PROXY environment variable the same way http.ProxyFromEnvironment does.
+func noProxyFromEnvironment() string {
+	if noProxy, ok := os.LookupEnv("NO_PROXY"); ok {
+		return noProxy
+	}
+	return os.Getenv("no_proxy")
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProxyFuncHonorsNoProxy(t *testing.T) {
+	const proxyURL = "http://proxy.corp.example:3128"
+	const noProxy = "mock.internal,.svc.cluster.local,10.0.0.0/8"
+
+	testCases := []struct {
+		requestURL string
+		proxied    bool
+	}{
+		{requestURL: "https://www.googleapis.com/siteVerification/v1/webResource", proxied: true},
+		{requestURL: "http://mock.internal:8080/siteVerification/v1/webResource", proxied: false},
+		{requestURL: "http://siteverification.svc.cluster.local/", proxied: false},
+		{requestURL: "http://10.1.2.3/", proxied: false},
+		{requestURL: "http://11.1.2.3/", proxied: true},
+		{requestURL: "http://notmock.internal/", proxied: true},
+	}
+
+	proxy := proxyFunc(proxyURL, noProxy)
+	for _, testCase := range testCases {
+		t.Run(testCase.requestURL, func(t *testing.T) {
+			request, requestErr := http.NewRequest(http.MethodGet, testCase.requestURL, nil)
+			if requestErr != nil {
+				t.Fatal(requestErr)
+			}
+
+			proxied, proxyErr := proxy(request)
+			if proxyErr != nil {
+				t.Fatal(proxyErr)
+			}
+
+			if testCase.proxied && (proxied == nil || proxied.String() != proxyURL) {
+				t.Errorf("expected the request to go through %s, got %v", proxyURL, proxied)
+			}
+			if !testCase.proxied && proxied != nil {
+				t.Errorf("expected the request not to be proxied, got %s", proxied)
+			}
+		})
+	}
+}
+
+func TestDialerUsesLocalAddress(t *testing.T) {
+	listener, listenErr := net.Listen("tcp", "127.0.0.1:0")
+	if listenErr != nil {
+		t.Fatal(listenErr)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Addr, 1)
+	go func() {
+		conn, acceptErr := listener.Accept()
+		if acceptErr != nil {
+			accepted <- nil
+			return
+		}
+		accepted <- conn.RemoteAddr()
+		_ = conn.Close()
+	}()
+
+	conn, dialErr := newDialer("127.0.0.1").DialContext(context.Background(), "tcp", listener.Addr().String())
+	if dialErr != nil {
+		t.Fatal(dialErr)
+	}
+	defer conn.Close()
+
+	if localIP := conn.LocalAddr().(*net.TCPAddr).IP; !localIP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("expected the connection to be opened from 127.0.0.1, got %s", localIP)
+	}
+	remoteAddr := <-accepted
+	if remoteAddr == nil {
+		t.Fatal("the connection was not accepted")
+	}
+	if remoteIP := remoteAddr.(*net.TCPAddr).IP; !remoteIP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("expected the server to see a connection from 127.0.0.1, got %s", remoteIP)
+	}
+}
+
+// roundTripperFunc adapts a function to an http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestThrottledTransport(t *testing.T) {
+	var inFlight, maxInFlight int32
+	transport := newThrottledTransport(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			observed := atomic.LoadInt32(&maxInFlight)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}), 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request, _ := http.NewRequest(http.MethodGet, "https://www.googleapis.com/", nil)
+			if _, roundTripErr := transport.RoundTrip(request); roundTripErr != nil {
+				t.Error(roundTripErr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 requests at once, got %d", maxInFlight)
+	}
+
+	// a request whose context is done does not wait for its turn
+	transport.semaphore <- struct{}{}
+	transport.semaphore <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.googleapis.com/", nil)
+	if _, roundTripErr := transport.RoundTrip(request); roundTripErr != context.Canceled {
+		t.Errorf("expected the canceled request to give up, got %v", roundTripErr)
+	}
+}
+
+func TestLoadCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+	validPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	directory := t.TempDir()
+	validFile := filepath.Join(directory, "ca.pem")
+	if writeErr := os.WriteFile(validFile, []byte(validPEM), 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	garbageFile := filepath.Join(directory, "garbage.pem")
+	if writeErr := os.WriteFile(garbageFile, []byte("not a certificate"), 0600); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	testCases := []struct {
+		name     string
+		caBundle string
+		valid    bool
+	}{
+		{name: "valid PEM", caBundle: validPEM, valid: true},
+		{name: "valid file", caBundle: validFile, valid: true},
+		{name: "garbage PEM", caBundle: "-----BEGIN CERTIFICATE-----\ngarbage\n-----END CERTIFICATE-----\n", valid: false},
+		{name: "garbage file", caBundle: garbageFile, valid: false},
+		{name: "missing file", caBundle: filepath.Join(directory, "missing.pem"), valid: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rootCAs, caBundleErr := loadCABundle(testCase.caBundle)
+			if (caBundleErr == nil) != testCase.valid {
+				t.Fatalf("expected valid=%t, got %v", testCase.valid, caBundleErr)
+			}
+			if !testCase.valid {
+				return
+			}
+			// the bundle is trusted to verify the server it was taken from
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}}}
+			response, getErr := client.Get(server.URL)
+			if getErr != nil {
+				t.Fatal(getErr)
+			}
+			response.Body.Close()
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const ownerChangeWebhookKey = "owner_change_webhook"
+const ownersKey = "owners"
+
+// ownerChange is the payload POSTed to the owner change webhook.
+type ownerChange struct {
+	Domain         string   `json:"domain"`
+	ID             string   `json:"id"`
+	Owners         []string `json:"owners"`
+	PreviousOwners []string `json:"previous_owners"`
+}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// notifyOwnerChange POSTs the owners to the webhook if they differ from the previous ones.
+// A failing webhook is logged, but never fails the operation.
+func notifyOwnerChange(webhookURL string, change ownerChange) {
+	if webhookURL == "" || sameOwners(change.Owners, change.PreviousOwners) {
+		return
+	}
+
+	// empty lists rather than nulls in the payload
+	change.Owners = append([]string{}, change.Owners...)
+	change.PreviousOwners = append([]string{}, change.PreviousOwners...)
+
+	payload, marshalErr := json.Marshal(change)
+	if marshalErr != nil {
+		log.Printf("[WARN] could not notify the owner change webhook of %s: %s", change.Domain, marshalErr)
+		return
+	}
+
+	response, postErr := webhookClient.Post(webhookURL, "application/json", bytes.NewReader(payload))
+	if postErr != nil {
+		log.Printf("[WARN] could not notify the owner change webhook of %s: %s", change.Domain, postErr)
+		return
+	}
+	_ = response.Body.Close()
+	if response.StatusCode >= 300 {
+		log.Printf("[WARN] could not notify the owner change webhook of %s: %s", change.Domain, fmt.Errorf("unexpected HTTP status %s", response.Status))
+	}
+}
+
+// sameOwners tells whether two lists hold the same owners, in any order and whatever their duplicates,
+// the emails being compared case-insensitively like uniqueOwners does.
+func sameOwners(a []string, b []string) bool {
+	setA := ownerSet(a)
+	setB := ownerSet(b)
+	if len(setA) != len(setB) {
+		return false
+	}
+	for owner := range setA {
+		if !setB[owner] {
+			return false
+		}
+	}
+	return true
+}
+
+// ownerSet returns the lowercased owners.
+func ownerSet(owners []string) map[string]bool {
+	set := map[string]bool{}
+	for _, owner := range owners {
+		set[strings.ToLower(owner)] = true
+	}
+	return set
+}
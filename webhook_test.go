@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSameOwners(t *testing.T) {
+	testCases := []struct {
+		a        []string
+		b        []string
+		expected bool
+	}{
+		{a: []string{"a@example.com", "b@example.com"}, b: []string{"b@example.com", "a@example.com"}, expected: true},
+		{a: []string{"A@Example.com"}, b: []string{"a@example.com"}, expected: true},
+		{a: []string{"a@example.com", "A@example.com"}, b: []string{"a@example.com"}, expected: true},
+		{a: nil, b: []string{}, expected: true},
+		{a: []string{"a@example.com"}, b: []string{"b@example.com"}, expected: false},
+		{a: []string{"a@example.com"}, b: []string{"a@example.com", "b@example.com"}, expected: false},
+	}
+
+	for _, testCase := range testCases {
+		if actual := sameOwners(testCase.a, testCase.b); actual != testCase.expected {
+			t.Errorf("expected sameOwners(%q, %q) to be %t, got %t", testCase.a, testCase.b, testCase.expected, actual)
+		}
+	}
+}
+
+func TestNotifyOwnerChange(t *testing.T) {
+	posts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+	}))
+	defer server.Close()
+
+	notifyOwnerChange(server.URL, ownerChange{Domain: "example.com", Owners: []string{"A@Example.com"}, PreviousOwners: []string{"a@example.com"}})
+	if posts != 0 {
+		t.Errorf("expected a different casing of the same owners not to be notified, got %d posts", posts)
+	}
+
+	notifyOwnerChange(server.URL, ownerChange{Domain: "example.com", Owners: []string{"b@example.com"}, PreviousOwners: []string{"a@example.com"}})
+	if posts != 1 {
+		t.Errorf("expected the changed owners to be notified once, got %d posts", posts)
+	}
+}
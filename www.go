@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const includeWwwKey = "include_www"
+const verifiedIDsKey = "verified_ids"
+
+// wwwVariant returns the www. variant of a domain, e.g. www.example.com for example.com.
+func wwwVariant(domain string) string {
+	return "www." + domain
+}
+
+// verifyWww verifies the www. variant of the resource's domain with its methods, if include_www is set.
+// Its TXT record is not waited for: the verification is retried until Google sees it or the timeout.
+func verifyWww(resourceData *schema.ResourceData, provider configuredProvider, timeout time.Duration, domain string, methods []string) error {
+	if !resourceData.Get(includeWwwKey).(bool) {
+		return nil
+	}
+	if _, insertErr := insertMethods(provider, timeout, siteType, wwwVariant(domain), methods); insertErr != nil {
+		return fmt.Errorf("verifying the %s variant of %s: %w", wwwVariant(domain), domain, insertErr)
+	}
+	return nil
+}
+
+// unverifyWww unverifies the www. variant of the resource's domain, if include_www is set.
+func unverifyWww(resourceData *schema.ResourceData, provider configuredProvider, timeout time.Duration, domain string) error {
+	if !resourceData.Get(includeWwwKey).(bool) {
+		return nil
+	}
+	return deleteSiteVerification(provider, timeout, webResourceID(wwwVariant(domain)))
+}
+
+// setVerifiedIDs sets the ids of the web resources verified by the resource, by domain.
+// It returns false if the www. variant is expected but not verified anymore.
+func setVerifiedIDs(resourceData *schema.ResourceData, provider configuredProvider, domain string) (bool, error) {
+	ids := map[string]interface{}{domain: resourceData.Id()}
+	if resourceData.Get(includeWwwKey).(bool) {
+		www := wwwVariant(domain)
+		if _, getErr := getWebResource(provider, webResourceID(www)); getErr != nil {
+			if httpStatusCode(getErr) == http.StatusNotFound {
+				log.Printf("[WARN] %s is not verified anymore", www)
+				return false, nil
+			}
+			return false, getErr
+		}
+		ids[www] = webResourceID(www)
+	}
+	return true, resourceData.Set(verifiedIDsKey, ids)
+}
+
+// checkIncludeWww returns an error if include_www is set for a web resource which is not a domain.
+func checkIncludeWww(includeWww bool, webResourceType string, identifier string) error {
+	if includeWww && (webResourceType != siteType || strings.HasPrefix(identifier, "www.")) {
+		return fmt.Errorf("%s only applies to the domains without a www. prefix, verified with a DNS method", includeWwwKey)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/terraform"
+	"google.golang.org/api/siteverification/v1"
+)
+
+func TestIncludeWww(t *testing.T) {
+	verified := map[string]bool{}
+	var deleted []string
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/token"):
+			var request siteverification.SiteVerificationWebResourceGettokenRequest
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			_ = json.NewEncoder(w).Encode(siteverification.SiteVerificationWebResourceGettokenResponse{Method: "DNS_TXT", Token: "google-site-verification=" + request.Site.Identifier})
+		case r.Method == http.MethodPost:
+			var webResource siteverification.SiteVerificationWebResourceResource
+			_ = json.NewDecoder(r.Body).Decode(&webResource)
+			verified[webResource.Site.Identifier] = true
+			webResource.Id = "dns://" + webResource.Site.Identifier
+			_ = json.NewEncoder(w).Encode(webResource)
+		default:
+			id, _ := url.PathUnescape(r.URL.EscapedPath()[strings.LastIndex(r.URL.EscapedPath(), "/")+1:])
+			domain := strings.TrimPrefix(id, "dns://")
+			if r.Method == http.MethodDelete {
+				deleted = append(deleted, domain)
+				_, _ = fmt.Fprint(w, `{}`)
+				return
+			}
+			if !verified[domain] {
+				writeAPIError(w, http.StatusNotFound, "Not Found")
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"id": %q, "site": {"identifier": %q, "type": "INET_DOMAIN"}}`, id, domain)
+		}
+	})
+
+	resourceData := schema.TestResourceDataRaw(t, Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"].Schema, map[string]interface{}{
+		domainKey:     "example.com",
+		tokenKey:      "google-site-verification=example.com",
+		dnsCheckKey:   false,
+		includeWwwKey: true,
+	})
+	if createErr := createDnsSiteVerification(resourceData, provider); createErr != nil {
+		t.Fatal(createErr)
+	}
+
+	expectedIDs := map[string]interface{}{"example.com": "dns://example.com", "www.example.com": "dns://www.example.com"}
+	if ids := resourceData.Get(verifiedIDsKey).(map[string]interface{}); !reflect.DeepEqual(ids, expectedIDs) {
+		t.Errorf("expected the ids %v, got %v", expectedIDs, ids)
+	}
+	if resourceData.Id() != "dns://example.com" {
+		t.Errorf("expected the id of the domain itself, got %s", resourceData.Id())
+	}
+	apexToken, apexErr := getToken(provider, "example.com", "DNS_TXT")
+	if apexErr != nil {
+		t.Fatal(apexErr)
+	}
+	wwwToken, wwwErr := getToken(provider, wwwVariant("example.com"), "DNS_TXT")
+	if wwwErr != nil {
+		t.Fatal(wwwErr)
+	}
+	if apexToken == wwwToken {
+		t.Errorf("expected the domain and its www. variant to get their own tokens, got %q for both", apexToken)
+	}
+
+	if deleteErr := deleteDnsSiteVerification(resourceData, provider); deleteErr != nil {
+		t.Fatal(deleteErr)
+	}
+	sort.Strings(deleted)
+	if strings.Join(deleted, ",") != "example.com,www.example.com" {
+		t.Errorf("expected both web resources to be unverified, got %v", deleted)
+	}
+}
+
+func TestCheckIncludeWww(t *testing.T) {
+	testCases := []struct {
+		includeWww      bool
+		webResourceType string
+		identifier      string
+		valid           bool
+	}{
+		{includeWww: false, webResourceType: "SITE", identifier: "https://example.com/", valid: true},
+		{includeWww: true, webResourceType: "INET_DOMAIN", identifier: "example.com", valid: true},
+		{includeWww: true, webResourceType: "INET_DOMAIN", identifier: "www.example.com", valid: false},
+		{includeWww: true, webResourceType: "SITE", identifier: "https://example.com/", valid: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("%t %s", testCase.includeWww, testCase.identifier), func(t *testing.T) {
+			if err := checkIncludeWww(testCase.includeWww, testCase.webResourceType, testCase.identifier); (err == nil) != testCase.valid {
+				t.Errorf("expected valid=%t, got %v", testCase.valid, err)
+			}
+		})
+	}
+}
+
+func TestUpdateVerifiesWwwOnce(t *testing.T) {
+	inserts := map[string]int{}
+	provider := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/token"):
+			_, _ = fmt.Fprint(w, `{"method": "DNS_TXT", "token": "google-site-verification=new"}`)
+		case r.Method == http.MethodPost:
+			var webResource siteverification.SiteVerificationWebResourceResource
+			_ = json.NewDecoder(r.Body).Decode(&webResource)
+			inserts[webResource.Site.Identifier]++
+			webResource.Id = "dns://" + webResource.Site.Identifier
+			_ = json.NewEncoder(w).Encode(webResource)
+		default:
+			_, _ = fmt.Fprint(w, `{"id": "dns://example.com", "site": {"identifier": "example.com", "type": "INET_DOMAIN"}}`)
+		}
+	})
+
+	dnsResource := Provider().(*schema.Provider).ResourcesMap["googlesiteverification_dns"]
+	state := &terraform.InstanceState{
+		ID: "dns://example.com",
+		Attributes: map[string]string{
+			"id":        "dns://example.com",
+			domainKey:   "example.com",
+			tokenKey:    "google-site-verification=old",
+			methodKey:   "DNS_TXT",
+			dnsCheckKey: "false",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		domainKey:     "example.com",
+		tokenKey:      "google-site-verification=new",
+		dnsCheckKey:   false,
+		includeWwwKey: true,
+	})
+	diff, diffErr := dnsResource.Diff(state, config, provider)
+	if diffErr != nil {
+		t.Fatal(diffErr)
+	}
+	if _, applyErr := dnsResource.Apply(state, diff, provider); applyErr != nil {
+		t.Fatal(applyErr)
+	}
+	if inserts["www.example.com"] != 1 {
+		t.Errorf("expected the www. variant to be verified once, got %d verifications", inserts["www.example.com"])
+	}
+}